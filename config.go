@@ -5,18 +5,37 @@
 package config
 
 import (
+	"context"
 	"errors"
+	"github.com/driscollos/config/internal/dumper"
 	"github.com/driscollos/config/internal/populator"
+	durationParser "github.com/driscollos/config/internal/populator/duration-parser"
 	"github.com/driscollos/config/internal/sourcer"
+	"github.com/driscollos/config/internal/structs"
+	"io"
+	"os"
+	"os/signal"
 	"reflect"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 )
 
-func New() Config {
+// New creates a Config. With no options, configuration is read from terminal arguments, environment variables and the
+// default configuration files in that order of priority. The files are read straight away, so a file created or
+// changed afterwards is only seen once the configuration is reloaded. The Config is safe to use from any number of
+// goroutines
+func New(opts ...Option) Config {
+	o := options{}
+	for _, option := range opts {
+		option(&o)
+	}
 	return config{
-		source: sourcer.New(),
+		dumper:   dumper.New(o.sourcer.EnvPrefix),
+		handles:  &handles{},
+		populate: o.populator,
+		source:   sourcer.New(o.sourcer),
 	}
 }
 
@@ -30,36 +49,137 @@ type Config interface {
 	// return value is FALSE
 	Bool(param string) bool
 
+	// BoolValue returns a handle holding the parameter whose name matches the param argument, converted as Bool
+	// converts it. The handle is kept up to date whenever the configuration is reloaded
+	BoolValue(param string) *BoolValue
+
 	// Date will attempt to convert the parameter whose name matches the param argument into a time.Time value - if the
 	// parameter is not known to the Config struct or there is an error with conversion this will be reflected in the
 	// error return value
 	Date(param, layout string) (time.Time, error)
 
+	// Dump writes every parameter known to the Config to w in the format given, after terminal arguments, environment
+	// variables and defaults used by Populate have been applied. This is the configuration as the Config sees it, with
+	// the secrets held by populated structs redacted
+	Dump(w io.Writer, format DumpFormat) error
+
+	// DumpStruct writes the container (struct) argument to w in the format given, showing exactly what Populate
+	// produced. Fields are keyed by the names Populate reads them from
+	DumpStruct(w io.Writer, container interface{}, format DumpFormat) error
+
+	// DurationValue returns a handle holding the parameter whose name matches the param argument, converted into a
+	// time.Duration in any of the formats Populate understands. The handle is kept up to date whenever the
+	// configuration is reloaded. The default value is 0, which is also given when the value is not a duration
+	DurationValue(param string) *DurationValue
+
+	// Err reports why the configuration could not be loaded - a file which could not be read or parsed, or an include
+	// which could not be followed, naming the chain of files which led to it. The default return value is nil, and
+	// while it is not nil every parameter is unknown. The files are only read again when the configuration is reloaded
+	// or the files are replaced
+	Err() error
+
+	// Explain describes where the parameter whose name matches the param argument came from - the source which
+	// supplied it, such as config.yml:12, $PORT or --Port, and any lower priority sources it overrides. Defaults from
+	// struct tags are included, and secrets redacted, once Populate has seen the fields they belong to
+	Explain(param string) Explanation
+
 	// Float will attempt to convert the parameter whose name matches the param argument into a float64 value. The default
 	// return value is 0
 	Float(param string) float64
 
+	// FloatValue returns a handle holding the parameter whose name matches the param argument, converted as Float
+	// converts it. The handle is kept up to date whenever the configuration is reloaded
+	FloatValue(param string) *FloatValue
+
 	// Int will attempt to convert the parameter whose name matches the param argument into an int value. The default
 	// return value is 0
 	Int(param string) int
 
+	// IntValue returns a handle holding the parameter whose name matches the param argument, converted as Int
+	// converts it. The handle is kept up to date whenever the configuration is reloaded
+	IntValue(param string) *IntValue
+
+	// OnChange registers a callback which is given the names of the parameters whose values changed each time the
+	// configuration is reloaded, either by Reload or because Watch noticed a file change
+	OnChange(callback func(changedKeys []string))
+
+	// OnError registers a callback which is told why a reload failed, whether a file could not be read or parsed or a
+	// struct given to Register could not be populated from it. The configuration already loaded is kept. The callback
+	// is also told, once each time the configuration is loaded, about values whose references cannot be resolved and
+	// which are used as written. A callback registered after loading is told about those already found
+	OnError(callback func(err error))
+
+	// Origin names the source which supplies the parameter whose name matches the param argument - the path of a
+	// file, "environment" or "command line". The default return value is ""
+	Origin(param string) string
+
 	// Populate will attempt to match the fields in the container (struct) argument to the parameters known to the Config
 	// struct. It will populate as many fields as it can, coverting them to the correct types. If there are any errors during
 	// population this will be reflected in the error return variable - this includes failing to populate fields which are marked
-	// as required:"true" in struct tags
+	// as required:"true" in struct tags. If the configuration could not be loaded the reason given by Err is returned
+	// and nothing is populated
 	Populate(container interface{}) error
 
+	// Provenance explains every parameter held in files, terminal arguments or other sources, along with every
+	// parameter given a default by Populate. Environment variables are included where they supply or override one of
+	// those parameters
+	Provenance() map[string]Explanation
+
+	// Register populates the container (struct) argument as Populate does, then populates a new copy of it each time
+	// the configuration is reloaded. The returned handle gives the latest copy. A reload is only accepted if every
+	// registered struct can be populated and passes Validate, if it implements Validator. Otherwise the configuration
+	// already loaded, and every handle, is left as it was. The container itself is never written to after Register
+	// returns, so it may be read without locking but is not updated by reloads
+	Register(container interface{}) (*StructValue, error)
+
+	// Reload reads every configuration file again. If a file cannot be read or parsed the configuration already loaded
+	// is kept and the error is returned
+	Reload() error
+
+	// ReloadOnSignal reloads the configuration each time the process receives one of the signals given, or SIGHUP if
+	// none are given, until ctx is done. Files and environment variables are read again and registered structs are
+	// populated again. Changes are reported to the callbacks registered with OnChange and failures to those registered
+	// with OnError
+	ReloadOnSignal(ctx context.Context, signals ...os.Signal)
+
 	// Source overrides all default information sourcing and explicitly uses the file at the path argument as the source of
-	// information used to provide configuration
+	// information used to provide configuration. The file is read when a value is next needed, or straight away if
+	// values have already been read - in which case it is checked and put in place in the same way as by Reload. If
+	// the file cannot be read the values already read are dropped
 	Source(path string)
 
+	// SourceFiles replaces the default configuration files with the files given, in priority order - the first file
+	// which knows a value provides it. Terminal arguments and environment variables still take priority over the files
+	// unless they have been switched off with UseOverrides. The files are read when a value is next needed, or straight
+	// away if values have already been read - in which case they are checked and put in place in the same way as by
+	// Reload. If the files cannot be read the values already read are dropped
+	SourceFiles(files ...SourceFile)
+
 	// String will attempt to convert the parameter whose name matches the param argument into a string value. The default
 	// return value is ""
 	String(param string) string
+
+	// StringValue returns a handle holding the parameter whose name matches the param argument. The handle is kept up
+	// to date whenever the configuration is reloaded
+	StringValue(param string) *StringValue
+
+	// UseOverrides controls whether terminal arguments and environment variables take priority over configuration
+	// files. They are used by default, but are switched off by Source. Values already read are refreshed in the same way
+	// as by SourceFiles
+	UseOverrides(enabled bool)
+
+	// Watch reloads the configuration whenever one of the files it was read from changes, until ctx is done. Files
+	// which would be read but do not exist yet are watched as well. A change which leaves a file unreadable keeps the
+	// configuration already loaded. The error return value reflects a failure to load the configuration to begin with,
+	// or a watch which is already running
+	Watch(ctx context.Context) error
 }
 
 type config struct {
-	source sourcer.Sourcer
+	dumper   dumper.Dumper
+	handles  *handles
+	populate populator.Options
+	source   sourcer.Sourcer
 }
 
 // Bool will attempt to convert the parameter whose name matches the param argument into a boolean. The default
@@ -72,6 +192,17 @@ func (c config) Bool(param string) bool {
 	return false
 }
 
+// BoolValue returns a handle holding the parameter whose name matches the param argument, converted as Bool
+// converts it. The handle is kept up to date whenever the configuration is reloaded
+func (c config) BoolValue(param string) *BoolValue {
+	return c.handles.handle(c, "bool", param, func() (interface{}, func()) {
+		handle := &BoolValue{}
+		return handle, func() {
+			handle.set(c.Bool(param))
+		}
+	}).(*BoolValue)
+}
+
 // Date will attempt to convert the parameter whose name matches the param argument into a time.Time value - if the
 // parameter is not known to the Config struct or there is an error with conversion this will be reflected in the
 // error return value
@@ -86,6 +217,17 @@ func (c config) Float(param string) float64 {
 	return val
 }
 
+// FloatValue returns a handle holding the parameter whose name matches the param argument, converted as Float
+// converts it. The handle is kept up to date whenever the configuration is reloaded
+func (c config) FloatValue(param string) *FloatValue {
+	return c.handles.handle(c, "float", param, func() (interface{}, func()) {
+		handle := &FloatValue{}
+		return handle, func() {
+			handle.set(c.Float(param))
+		}
+	}).(*FloatValue)
+}
+
 // Int will attempt to convert the parameter whose name matches the param argument into an int value. The default
 // return value is 0
 func (c config) Int(param string) int {
@@ -93,15 +235,95 @@ func (c config) Int(param string) int {
 	return val
 }
 
+// IntValue returns a handle holding the parameter whose name matches the param argument, converted as Int
+// converts it. The handle is kept up to date whenever the configuration is reloaded
+func (c config) IntValue(param string) *IntValue {
+	return c.handles.handle(c, "int", param, func() (interface{}, func()) {
+		handle := &IntValue{}
+		return handle, func() {
+			handle.set(c.Int(param))
+		}
+	}).(*IntValue)
+}
+
+// Dump writes every parameter known to the Config to w in the format given, after terminal arguments, environment
+// variables and defaults used by Populate have been applied. This is the configuration as the Config sees it, with
+// the secrets held by populated structs redacted
+func (c config) Dump(w io.Writer, format DumpFormat) error {
+	return c.dumper.Dump(w, c.source.Values(), format)
+}
+
+// DumpStruct writes the container (struct) argument to w in the format given, showing exactly what Populate
+// produced. Fields are keyed by the names Populate reads them from
+func (c config) DumpStruct(w io.Writer, container interface{}, format DumpFormat) error {
+	values, err := c.dumper.Structure(container)
+	if err != nil {
+		return err
+	}
+	return c.dumper.Dump(w, values, format)
+}
+
+// DurationValue returns a handle holding the parameter whose name matches the param argument, converted into a
+// time.Duration in any of the formats Populate understands. The handle is kept up to date whenever the
+// configuration is reloaded. The default value is 0, which is also given when the value is not a duration
+func (c config) DurationValue(param string) *DurationValue {
+	return c.handles.handle(c, "duration", param, func() (interface{}, func()) {
+		handle := &DurationValue{}
+		return handle, func() {
+			duration, _ := durationParser.New().Parse(c.source.Get(param))
+			handle.set(duration)
+		}
+	}).(*DurationValue)
+}
+
+// Err reports why the configuration could not be loaded - a file which could not be read or parsed, or an include
+// which could not be followed, naming the chain of files which led to it. The default return value is nil, and while
+// it is not nil every parameter is unknown. The files are only read again when the configuration is reloaded or the
+// files are replaced
+func (c config) Err() error {
+	return c.source.Err()
+}
+
+// Explain describes where the parameter whose name matches the param argument came from - the source which
+// supplied it, such as config.yml:12, $PORT or --Port, and any lower priority sources it overrides. Defaults from
+// struct tags are included, and secrets redacted, once Populate has seen the fields they belong to
+func (c config) Explain(param string) Explanation {
+	return c.source.Explain(param)
+}
+
+// OnChange registers a callback which is given the names of the parameters whose values changed each time the
+// configuration is reloaded, either by Reload or because Watch noticed a file change
+func (c config) OnChange(callback func(changedKeys []string)) {
+	c.source.OnChange(callback)
+}
+
+// OnError registers a callback which is told why a reload failed, whether a file could not be read or parsed or a
+// struct given to Register could not be populated from it. The configuration already loaded is kept. The callback is
+// also told, once each time the configuration is loaded, about values whose references cannot be resolved and which
+// are used as written. A callback registered after loading is told about those already found
+func (c config) OnError(callback func(err error)) {
+	c.source.OnError(callback)
+}
+
+// Origin names the source which supplies the parameter whose name matches the param argument - the path of a
+// file, "environment" or "command line". The default return value is ""
+func (c config) Origin(param string) string {
+	return c.source.Origin(param)
+}
+
 // Populate will attempt to match the fields in the container (struct) argument to the parameters known to the Config
 // struct. It will populate as many fields as it can, coverting them to the correct types. If there are any errors during
 // population this will be reflected in the error return variable - this includes failing to populate fields which are marked
-// as required:"true" in struct tags
+// as required:"true" in struct tags. If the configuration could not be loaded the reason given by Err is returned
+// and nothing is populated
 func (c config) Populate(container interface{}) error {
 	if reflect.ValueOf(container).Kind() == reflect.Struct {
 		return errors.New("pass a pointer to Populate() instead of a struct i.e. Populate(&myConfig)")
 	}
-	p := populator.New(c.source)
+	if err := c.source.Err(); err != nil {
+		return err
+	}
+	p := populator.New(c.source, c.populate)
 	return p.Populate(container)
 }
 
@@ -111,8 +333,126 @@ func (c config) String(param string) string {
 	return c.source.Get(param)
 }
 
-// String will attempt to convert the parameter whose name matches the param argument into a string value. The default
-// return value is ""
+// StringValue returns a handle holding the parameter whose name matches the param argument. The handle is kept up
+// to date whenever the configuration is reloaded
+func (c config) StringValue(param string) *StringValue {
+	return c.handles.handle(c, "string", param, func() (interface{}, func()) {
+		handle := &StringValue{}
+		return handle, func() {
+			handle.set(c.String(param))
+		}
+	}).(*StringValue)
+}
+
+// Provenance explains every parameter held in files, terminal arguments or other sources, along with every
+// parameter given a default by Populate. Environment variables are included where they supply or override one of
+// those parameters
+func (c config) Provenance() map[string]Explanation {
+	return c.source.Provenance()
+}
+
+// Register populates the container (struct) argument as Populate does, then populates a new copy of it each time the
+// configuration is reloaded. The returned handle gives the latest copy. A reload is only accepted if every registered
+// struct can be populated and passes Validate, if it implements Validator. Otherwise the configuration already
+// loaded, and every handle, is left as it was. The container itself is never written to after Register returns, so
+// it may be read without locking but is not updated by reloads
+func (c config) Register(container interface{}) (*StructValue, error) {
+	target := reflect.ValueOf(container)
+	if target.Kind() != reflect.Ptr || target.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("pass a pointer to a struct to Register() i.e. Register(&myConfig)")
+	}
+
+	// values set before the struct was registered are kept as the starting point of every reload
+	initial := reflect.New(target.Elem().Type())
+	initial.Elem().Set(target.Elem())
+
+	if err := c.Populate(container); err != nil {
+		return nil, err
+	}
+	if err := validate(container); err != nil {
+		return nil, err
+	}
+
+	// each copy stored in the handle is never changed once stored, so readers only ever copy from it
+	handle := &StructValue{}
+	snapshot := reflect.New(target.Elem().Type())
+	snapshot.Elem().Set(target.Elem())
+	handle.set(snapshot)
+
+	c.source.Validate(func(lookup structs.Lookup) (func(), error) {
+		fresh := reflect.New(target.Elem().Type())
+		fresh.Elem().Set(initial.Elem())
+		if err := populator.New(lookup, c.populate).Populate(fresh.Interface()); err != nil {
+			return nil, err
+		}
+		if err := validate(fresh.Interface()); err != nil {
+			return nil, err
+		}
+		return func() {
+			handle.set(fresh)
+		}, nil
+	})
+	return handle, nil
+}
+
+// Reload reads every configuration file again. If a file cannot be read or parsed the configuration already loaded
+// is kept and the error is returned
+func (c config) Reload() error {
+	return c.source.Reload()
+}
+
+// ReloadOnSignal reloads the configuration each time the process receives one of the signals given, or SIGHUP if
+// none are given, until ctx is done. Files and environment variables are read again and registered structs are
+// populated again. Changes are reported to the callbacks registered with OnChange and failures to those registered
+// with OnError
+func (c config) ReloadOnSignal(ctx context.Context, signals ...os.Signal) {
+	if len(signals) < 1 {
+		signals = []os.Signal{syscall.SIGHUP}
+	}
+
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, signals...)
+	go func() {
+		defer signal.Stop(received)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-received:
+				c.source.Reload()
+			}
+		}
+	}()
+}
+
+// Source overrides all default information sourcing and explicitly uses the file at the path argument as the source of
+// information used to provide configuration. The file is read when a value is next needed, or straight away if
+// values have already been read - in which case it is checked and put in place in the same way as by Reload. If
+// the file cannot be read the values already read are dropped
 func (c config) Source(path string) {
 	c.source.Source(path)
 }
+
+// SourceFiles replaces the default configuration files with the files given, in priority order - the first file
+// which knows a value provides it. Terminal arguments and environment variables still take priority over the files
+// unless they have been switched off with UseOverrides. The files are read when a value is next needed, or straight
+// away if values have already been read - in which case they are checked and put in place in the same way as by
+// Reload. If the files cannot be read the values already read are dropped
+func (c config) SourceFiles(files ...SourceFile) {
+	c.source.SourceFiles(files)
+}
+
+// UseOverrides controls whether terminal arguments and environment variables take priority over configuration
+// files. They are used by default, but are switched off by Source. Values already read are refreshed in the same way
+// as by SourceFiles
+func (c config) UseOverrides(enabled bool) {
+	c.source.UseOverrides(enabled)
+}
+
+// Watch reloads the configuration whenever one of the files it was read from changes, until ctx is done. Files
+// which would be read but do not exist yet are watched as well. A change which leaves a file unreadable keeps the
+// configuration already loaded. The error return value reflects a failure to load the configuration to begin with,
+// or a watch which is already running
+func (c config) Watch(ctx context.Context) error {
+	return c.source.Watch(ctx)
+}
@@ -5,12 +5,23 @@
 package config
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/driscollos/config/internal/mocks"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v3"
 )
 
 func TestSuite(t *testing.T) {
@@ -18,23 +29,59 @@ func TestSuite(t *testing.T) {
 	RunSpecs(t, "Unit Tests")
 }
 
+func signalSelf(sig os.Signal) error {
+	process, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		return err
+	}
+	return process.Signal(sig)
+}
+
+type validatedConfig struct {
+	Port int `required:"true"`
+	Host string
+}
+
+func (v *validatedConfig) Validate() error {
+	if v.Port < 1024 {
+		return fmt.Errorf("port %d is reserved", v.Port)
+	}
+	return nil
+}
+
 var _ = Describe("Config Unit Tests", func() {
 	var (
 		mockController *gomock.Controller
 		mockSourcer    *mocks.MockSourcer
 		myConf         config
+		dir            string
 	)
 
+	// write saves content as the file name within dir, replacing any file already there in one step so that readers
+	// never see it half written
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		Expect(os.WriteFile(path+".tmp", []byte(content), 0644)).To(BeNil())
+		Expect(os.Rename(path+".tmp", path)).To(BeNil())
+		return path
+	}
+
 	BeforeEach(func() {
 		mockController = gomock.NewController(GinkgoT())
 		mockSourcer = mocks.NewMockSourcer(mockController)
 		myConf = config{
-			source: mockSourcer,
+			handles: &handles{},
+			source:  mockSourcer,
 		}
+
+		var err error
+		dir, err = os.MkdirTemp("", "config")
+		Expect(err).To(BeNil())
 	})
 
 	AfterEach(func() {
 		mockController.Finish()
+		Expect(os.RemoveAll(dir)).To(BeNil())
 	})
 
 	Context("Config", func() {
@@ -58,5 +105,410 @@ var _ = Describe("Config Unit Tests", func() {
 				}
 			})
 		})
+
+		When("the configuration cannot be loaded", func() {
+			It("should report why from Err and Populate", func() {
+				path := write("config.yml", "include: common.yml\nPort: 8080")
+				common := write("common.yml", "include: missing.yml")
+
+				conf := New(WithSources(File(path)))
+				expected := fmt.Sprintf(
+					"could not read included file : %s : included by %s -> %s",
+					filepath.Join(dir, "missing.yml"), path, common,
+				)
+				Expect(conf.Err()).To(MatchError(expected))
+				myConfig := validatedConfig{}
+				Expect(conf.Populate(&myConfig)).To(MatchError(expected))
+				Expect(myConfig).To(Equal(validatedConfig{}))
+			})
+		})
+
+		When("the configuration files are watched", func() {
+			It("should reload a file when it changes and report the keys which changed", func() {
+				path := write("config.yml", "Port: 8080\nHost: localhost")
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				changes := make(chan []string, 1)
+				conf := New(WithSources(File(path)), WithPolling(10*time.Millisecond))
+				conf.OnChange(func(changedKeys []string) {
+					changes <- changedKeys
+				})
+				Expect(conf.Watch(ctx)).To(BeNil())
+				Expect(conf.Int("Port")).To(Equal(8080))
+
+				write("config.yml", "Port: 9090\nHost: localhost\n")
+				Eventually(changes).Should(Receive(Equal([]string{"Port"})))
+				Expect(conf.Int("Port")).To(Equal(9090))
+			})
+		})
+
+		When("the configuration files are replaced", func() {
+			It("should refresh the handles already given out", func() {
+				path := write("config.yml", "Db:\n  Url: postgres://localhost")
+				other := write("other.yml", "Db:\n  Url: postgres://db.internal")
+
+				conf := New(WithSources(File(path)))
+				changes := make([][]string, 0)
+				conf.OnChange(func(changedKeys []string) {
+					changes = append(changes, changedKeys)
+				})
+				handle := conf.StringValue("Db_Url")
+				Expect(handle.Get()).To(Equal("postgres://localhost"))
+
+				conf.Source(other)
+				Expect(handle.Get()).To(Equal("postgres://db.internal"))
+				Expect(conf.Reload()).To(BeNil())
+				Expect(handle.Get()).To(Equal("postgres://db.internal"))
+				Expect(changes).To(Equal([][]string{{"Db_Url"}}))
+			})
+		})
+
+		When("the configuration is reloaded on a signal", func() {
+			It("should reload when SIGHUP is received", func() {
+				path := write("config.yml", "Port: 8080")
+
+				changes := make(chan []string, 1)
+				conf := New(WithSources(File(path)))
+				conf.OnChange(func(changedKeys []string) {
+					changes <- changedKeys
+				})
+				Expect(conf.Int("Port")).To(Equal(8080))
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				conf.ReloadOnSignal(ctx)
+				write("config.yml", "Port: 9090\nHost: localhost")
+				Expect(signalSelf(syscall.SIGHUP)).To(BeNil())
+				Eventually(changes).Should(Receive(Equal([]string{"Host", "Port"})))
+				Expect(conf.Int("Port")).To(Equal(9090))
+			})
+		})
+
+		When("a struct is registered", func() {
+			It("should only accept reloads which populate and validate the struct", func() {
+				path := write("config.yml", "Port: 8080\nHost: localhost")
+				failures := make([]string, 0)
+				conf := New(WithSources(File(path)))
+				conf.OnError(func(err error) {
+					failures = append(failures, err.Error())
+				})
+
+				myConfig := validatedConfig{}
+				handle, err := conf.Register(&myConfig)
+				Expect(err).To(BeNil())
+				Expect(myConfig).To(Equal(validatedConfig{Port: 8080, Host: "localhost"}))
+				Expect(handle.Get()).To(Equal(&validatedConfig{Port: 8080, Host: "localhost"}))
+
+				write("config.yml", "Host: db.internal")
+				Expect(conf.Reload()).To(MatchError("missing required value : Port"))
+				write("config.yml", "Port: 80")
+				Expect(conf.Reload()).To(MatchError("port 80 is reserved"))
+				write("config.yml", "Port: [80")
+				Expect(conf.Reload()).ToNot(BeNil())
+				Expect(handle.Get()).To(Equal(&validatedConfig{Port: 8080, Host: "localhost"}))
+				Expect(conf.Int("Port")).To(Equal(8080))
+				Expect(failures).To(HaveLen(3))
+
+				write("config.yml", "Port: 9090\nHost: db.internal")
+				Expect(conf.Reload()).To(BeNil())
+				Expect(handle.Get()).To(Equal(&validatedConfig{Port: 9090, Host: "db.internal"}))
+				Expect(conf.Int("Port")).To(Equal(9090))
+				Expect(myConfig).To(Equal(validatedConfig{Port: 8080, Host: "localhost"}))
+			})
+
+			It("should validate and refresh the struct when the configuration files are replaced", func() {
+				first := write("a.yml", "Port: 8080")
+				reserved := write("b.yml", "Port: 80")
+				replaced := write("c.yml", "Port: 9090")
+
+				failures := make([]string, 0)
+				conf := New(WithSources(File(first)))
+				conf.OnError(func(err error) {
+					failures = append(failures, err.Error())
+				})
+				handle, err := conf.Register(&validatedConfig{})
+				Expect(err).To(BeNil())
+
+				conf.SourceFiles(Required(reserved))
+				Expect(failures).To(Equal([]string{"port 80 is reserved"}))
+				Expect(conf.Int("Port")).To(Equal(8080))
+				Expect(handle.Get()).To(Equal(&validatedConfig{Port: 8080}))
+
+				conf.SourceFiles(Required(replaced))
+				Expect(conf.Int("Port")).To(Equal(9090))
+				Expect(handle.Get()).To(Equal(&validatedConfig{Port: 9090}))
+			})
+
+			It("should hand out whole copies while reloads run", func() {
+				path := write("config.yml", "Port: 8080\nHost: localhost")
+
+				conf := New(WithSources(File(path)))
+				myConfig := validatedConfig{}
+				handle, err := conf.Register(&myConfig)
+				Expect(err).To(BeNil())
+
+				var wait sync.WaitGroup
+				for reader := 0; reader < 4; reader++ {
+					wait.Add(1)
+					go func() {
+						defer GinkgoRecover()
+						defer wait.Done()
+						for x := 0; x < 100; x++ {
+							current := handle.Get().(*validatedConfig)
+							Expect([]validatedConfig{
+								{Port: 8080, Host: "localhost"},
+								{Port: 9090, Host: "db.internal"},
+							}).To(ContainElement(*current))
+							current.Port = 1
+							Expect(myConfig).To(Equal(validatedConfig{Port: 8080, Host: "localhost"}))
+						}
+					}()
+				}
+				for x := 0; x < 50; x++ {
+					if x%2 == 0 {
+						write("config.yml", "Port: 9090\nHost: db.internal")
+					} else {
+						write("config.yml", "Port: 8080\nHost: localhost")
+					}
+					Expect(conf.Reload()).To(BeNil())
+				}
+				wait.Wait()
+			})
+
+			It("should refuse a struct which fails validation to begin with", func() {
+				mockSourcer.EXPECT().Err().Return(nil)
+				mockSourcer.EXPECT().Separator().Return("_").AnyTimes()
+				mockSourcer.EXPECT().Get(gomock.Any(), gomock.Any()).Return("80").AnyTimes()
+				myConfig := validatedConfig{}
+				handle, err := myConf.Register(&myConfig)
+				Expect(err).To(MatchError("port 80 is reserved"))
+				Expect(handle).To(BeNil())
+				_, err = myConf.Register(myConfig)
+				Expect(err).ToNot(BeNil())
+			})
+		})
+
+		When("the Config is shared between goroutines", func() {
+			It("should be safe to read, populate and reload at the same time", func() {
+				path := write("config.yml", "Port: 8080\nHost: localhost")
+
+				type settings struct {
+					Port    int
+					Host    string
+					Timeout time.Duration `default:"5s"`
+					Secret  Secret
+				}
+				conf := New(WithSources(File(path)))
+				registered, err := conf.Register(&settings{})
+				Expect(err).To(BeNil())
+				port := conf.IntValue("Port")
+
+				var wait sync.WaitGroup
+				run := func(task func(x int)) {
+					wait.Add(1)
+					go func() {
+						defer GinkgoRecover()
+						defer wait.Done()
+						for x := 0; x < 50; x++ {
+							task(x)
+						}
+					}()
+				}
+
+				for reader := 0; reader < 4; reader++ {
+					run(func(x int) {
+						Expect(conf.Int("Port")).To(BeElementOf(8080, 9090))
+						Expect(port.Get()).To(BeElementOf(8080, 9090))
+						Expect(registered.Get().(*settings).Port).To(BeElementOf(8080, 9090))
+						local := settings{}
+						Expect(conf.Populate(&local)).To(BeNil())
+						Expect(local.Port).To(BeElementOf(8080, 9090))
+						Expect(conf.Dump(io.Discard, FormatYAML)).To(BeNil())
+						conf.Explain("Host")
+					})
+				}
+				run(func(x int) {
+					if x%2 == 0 {
+						write("config.yml", "Port: 9090\nHost: db.internal")
+					} else {
+						write("config.yml", "Port: 8080\nHost: localhost")
+					}
+					Expect(conf.Reload()).To(BeNil())
+				})
+				run(func(x int) {
+					conf.SourceFiles(Required(path))
+					conf.OnChange(func(changedKeys []string) {})
+				})
+				wait.Wait()
+			})
+		})
+
+		When("handles on values are used", func() {
+			var reload func(changedKeys []string)
+
+			BeforeEach(func() {
+				reload = nil
+				mockSourcer.EXPECT().Separator().Return("_")
+				mockSourcer.EXPECT().OnChange(gomock.Any()).Do(func(callback func(changedKeys []string)) {
+					reload = callback
+				})
+			})
+
+			It("should keep a string up to date", func() {
+				mockSourcer.EXPECT().Get("Host").Return("localhost")
+				mockSourcer.EXPECT().Get("Host").Return("db.internal")
+				handle := myConf.StringValue("Host")
+				Expect(handle.Get()).To(Equal("localhost"))
+				reload([]string{"Host"})
+				Expect(handle.Get()).To(Equal("db.internal"))
+			})
+
+			It("should keep an int up to date", func() {
+				mockSourcer.EXPECT().Get("Port").Return("8080")
+				mockSourcer.EXPECT().Get("Port").Return("not a number")
+				handle := myConf.IntValue("Port")
+				Expect(handle.Get()).To(Equal(8080))
+				reload([]string{"Port"})
+				Expect(handle.Get()).To(Equal(0))
+			})
+
+			It("should keep a float up to date", func() {
+				mockSourcer.EXPECT().Get("Ratio").Return("0.5")
+				mockSourcer.EXPECT().Get("Ratio").Return("1.25")
+				handle := myConf.FloatValue("Ratio")
+				Expect(handle.Get()).To(Equal(0.5))
+				reload([]string{"Ratio"})
+				Expect(handle.Get()).To(Equal(1.25))
+			})
+
+			It("should keep a bool up to date", func() {
+				mockSourcer.EXPECT().Get("Debug").Return("yes")
+				mockSourcer.EXPECT().Get("Debug").Return("off")
+				handle := myConf.BoolValue("Debug")
+				Expect(handle.Get()).To(BeTrue())
+				reload([]string{"Debug"})
+				Expect(handle.Get()).To(BeFalse())
+			})
+
+			It("should keep a duration up to date", func() {
+				mockSourcer.EXPECT().Get("Timeout").Return("1 min 30 secs")
+				mockSourcer.EXPECT().Get("Timeout").Return("2 hours")
+				handle := myConf.DurationValue("Timeout")
+				Expect(handle.Get()).To(Equal(90 * time.Second))
+				reload([]string{"Timeout"})
+				Expect(handle.Get()).To(Equal(2 * time.Hour))
+			})
+
+			It("should give a duration of 0 for a value which is not a duration", func() {
+				mockSourcer.EXPECT().Get("Timeout").Return("soon")
+				Expect(myConf.DurationValue("Timeout").Get()).To(Equal(time.Duration(0)))
+			})
+
+			It("should give out one handle for each parameter and only refresh it when its value changes", func() {
+				mockSourcer.EXPECT().Get("Db").Return(`"Host":"localhost"`)
+				mockSourcer.EXPECT().Get("Port").Return("8080")
+				db := myConf.StringValue("Db")
+				port := myConf.IntValue("Port")
+				Expect(myConf.StringValue("Db")).To(BeIdenticalTo(db))
+				Expect(myConf.IntValue("Port")).To(BeIdenticalTo(port))
+
+				reload([]string{"Name", "Portal"})
+
+				mockSourcer.EXPECT().Get("Db").Return(`"Host":"db.internal"`)
+				reload([]string{"DB_HOST"})
+				Expect(db.Get()).To(Equal(`"Host":"db.internal"`))
+				Expect(port.Get()).To(Equal(8080))
+			})
+
+			It("should be safe to read while the configuration is reloaded", func() {
+				mockSourcer.EXPECT().Get("Limit").Return("10").AnyTimes()
+				handle := myConf.IntValue("Limit")
+				done := make(chan bool)
+				go func() {
+					defer close(done)
+					for x := 0; x < 100; x++ {
+						reload([]string{"Limit"})
+					}
+				}()
+				for x := 0; x < 100; x++ {
+					Expect(handle.Get()).To(Equal(10))
+				}
+				<-done
+			})
+		})
+
+		When("a Secret is printed or marshalled", func() {
+			It("should never show its value", func() {
+				password := Secret("hunter2")
+				Expect(password.Value()).To(Equal("hunter2"))
+				for _, text := range []string{
+					password.String(),
+					fmt.Sprint(password),
+					fmt.Sprintf("%s %v %+v %x", password, password, password, password),
+					fmt.Sprintf("%#v %q", password, password),
+					fmt.Sprintf("%v", struct{ Password Secret }{password}),
+				} {
+					Expect(text).ToNot(ContainSubstring("hunter2"))
+				}
+
+				asJson, err := json.Marshal(struct{ Password Secret }{password})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(asJson)).To(Equal(`{"Password":"[REDACTED]"}`))
+
+				asYaml, err := yaml.Marshal(struct{ Password Secret }{password})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(asYaml)).To(Equal("password: '[REDACTED]'\n"))
+			})
+		})
+
+		When("secrets are explained or dumped", func() {
+			It("should only redact them once a struct holding them has been populated", func() {
+				path := write("config.yml", "Db:\n  Password: hunter2\n  Url: postgres://app:${Db_Password}@db")
+
+				conf := New(WithSources(File(path)))
+				Expect(conf.Explain("Db_Password").Value).To(Equal("hunter2"))
+
+				settings := struct {
+					Db struct {
+						Password Secret
+						Url      string
+					}
+				}{}
+				Expect(conf.Populate(&settings)).To(BeNil())
+				Expect(settings.Db.Url).To(Equal("postgres://app:hunter2@db"))
+				Expect(conf.Explain("Db_Password").Value).To(Equal("[REDACTED]"))
+				Expect(conf.Explain("Db_Url").Value).To(Equal("[REDACTED]"))
+
+				dumped := &strings.Builder{}
+				Expect(conf.Dump(dumped, FormatYAML)).To(BeNil())
+				Expect(dumped.String()).ToNot(ContainSubstring("hunter2"))
+			})
+
+			It("should never give them to OnError when their references cannot be resolved", func() {
+				path := write("config.yml", "Db:\n  Password: ab${cd\nLabels:\n  Team: ${Quoted}\nQuoted: say \"hi\"")
+
+				conf := New(WithSources(File(path)))
+				failures := make([]string, 0)
+				conf.OnError(func(err error) {
+					failures = append(failures, err.Error())
+					conf.Explain("Db")
+				})
+
+				settings := struct {
+					Db struct {
+						Password Secret
+					}
+					Labels map[string]string
+				}{}
+				Expect(conf.Populate(&settings)).To(BeNil())
+				Expect(settings.Db.Password.Value()).To(Equal("ab${cd"))
+				Expect(settings.Labels).To(HaveKey("Team"))
+				Expect(conf.String("Labels_Team")).To(Equal(`say "hi"`))
+				Expect(failures).To(Equal([]string{
+					"could not expand the value of Db_Password : unterminated reference starting at character 3",
+				}))
+			})
+		})
 	})
 })
@@ -0,0 +1,24 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package config
+
+import (
+	"github.com/driscollos/config/internal/structs"
+)
+
+// DumpFormat chooses how Dump and DumpStruct write configuration
+type DumpFormat = structs.DumpFormat
+
+const (
+	// FormatEnv writes KEY=value lines, with each level of nesting joined by an underscore and any prefix given with
+	// WithEnvPrefix added to the names. The output can be read back as a dotenv file
+	FormatEnv = structs.DumpEnv
+
+	// FormatJSON writes indented json
+	FormatJSON = structs.DumpJSON
+
+	// FormatYAML writes yaml
+	FormatYAML = structs.DumpYAML
+)
@@ -0,0 +1,16 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package config
+
+import (
+	"github.com/driscollos/config/internal/structs"
+)
+
+// Explanation describes where a value came from - the source which supplied it and any lower priority sources it
+// overrides. Its String method gives a single line summary suitable for logging
+type Explanation = structs.Explanation
+
+// Candidate is a value which one source holds for a key, along with where in the source it was found
+type Candidate = structs.Candidate
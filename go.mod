@@ -3,6 +3,8 @@ module github.com/driscollos/config
 go 1.16
 
 require (
+	github.com/BurntSushi/toml v1.2.1
+	github.com/fsnotify/fsnotify v1.4.9
 	github.com/golang/mock v1.6.0
 	github.com/onsi/ginkgo v1.16.5
 	github.com/onsi/gomega v1.24.2
@@ -0,0 +1,10 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package dumper
+
+const (
+	ErrorNotStruct     = "please supply a struct or a pointer to a struct to dump"
+	ErrorUnknownFormat = "unknown dump format : %s"
+)
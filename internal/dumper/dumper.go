@@ -0,0 +1,215 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package dumper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/driscollos/config/internal/structs"
+)
+
+// Dumper writes configuration values out as yaml, json or KEY=value lines
+type Dumper interface {
+	Dump(w io.Writer, values map[string]interface{}, format structs.DumpFormat) error
+	Structure(container interface{}) (map[string]interface{}, error)
+}
+
+type dumper struct {
+	envPrefix string
+}
+
+var unquoted = regexp.MustCompile(`^[A-Za-z0-9_./:@,+-]*$`)
+
+func (d dumper) Dump(w io.Writer, values map[string]interface{}, format structs.DumpFormat) error {
+	switch format {
+	case structs.DumpYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(values); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case structs.DumpJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(values)
+	case structs.DumpEnv:
+		lines := d.env(values, "")
+		sort.Strings(lines)
+		for _, line := range lines {
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf(ErrorUnknownFormat, format)
+}
+
+// env flattens values into KEY=value lines, where each level of nesting is joined by an underscore
+func (d dumper) env(data interface{}, name string) []string {
+	lines := make([]string, 0)
+	switch typed := data.(type) {
+	case map[string]interface{}:
+		for key, val := range typed {
+			lines = append(lines, d.env(val, d.join(name, key))...)
+		}
+	case []interface{}:
+		for pos, val := range typed {
+			lines = append(lines, d.env(val, d.join(name, strconv.Itoa(pos)))...)
+		}
+	case nil:
+	default:
+		variable := strings.ToUpper(strings.Replace(d.envPrefix+name, " ", "_", -1))
+		lines = append(lines, fmt.Sprintf("%s=%s", variable, d.quote(fmt.Sprint(typed))))
+	}
+	return lines
+}
+
+func (d dumper) join(name, key string) string {
+	if len(name) < 1 {
+		return key
+	}
+	return name + "_" + key
+}
+
+// quote protects values which a dotenv file would otherwise misread. Single quotes are preferred as their content is
+// used exactly as written. Values which cannot be single quoted are double quoted, escaping anything a dotenv file
+// would expand or treat specially
+func (d dumper) quote(value string) string {
+	switch {
+	case unquoted.MatchString(value):
+		return value
+	case !strings.ContainsAny(value, "'\n\r"):
+		return "'" + value + "'"
+	}
+
+	var quoted strings.Builder
+	quoted.WriteByte('"')
+	for _, c := range value {
+		switch c {
+		case '\\', '"', '$', '`':
+			quoted.WriteRune('\\')
+			quoted.WriteRune(c)
+		case '\n':
+			quoted.WriteString(`\n`)
+		case '\r':
+			quoted.WriteString(`\r`)
+		case '\t':
+			quoted.WriteString(`\t`)
+		default:
+			quoted.WriteRune(c)
+		}
+	}
+	quoted.WriteByte('"')
+	return quoted.String()
+}
+
+// Structure converts a populated struct into nested values, ready to be dumped. Fields are keyed by the names Populate
+// reads them from - the name in a src, yaml or json tag, or otherwise the field name - so that the values can be read
+// back in. As a src tag names a key from the top level, fields with one are placed at the top level. Durations and
+// times are written as text, as they would be given in configuration
+func (d dumper) Structure(container interface{}) (map[string]interface{}, error) {
+	v := reflect.ValueOf(container)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, errors.New(ErrorNotStruct)
+	}
+
+	root := make(map[string]interface{})
+	d.fields(v, root, root)
+	return root, nil
+}
+
+// structure converts a single value. root holds the top level values, where fields with a src tag are placed
+func (d dumper) structure(v reflect.Value, root map[string]interface{}) interface{} {
+	switch val := v.Interface().(type) {
+	case structs.Secret:
+		return structs.Redacted
+	case time.Duration:
+		return val.String()
+	case time.Time:
+		return val.Format(time.RFC3339)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return d.structure(v.Elem(), root)
+	case reflect.Struct:
+		values := make(map[string]interface{})
+		d.fields(v, values, root)
+		return values
+	case reflect.Map:
+		values := make(map[string]interface{})
+		for _, key := range v.MapKeys() {
+			values[fmt.Sprint(key.Interface())] = d.structure(v.MapIndex(key), root)
+		}
+		return values
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes())
+		}
+		values := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			values = append(values, d.structure(v.Index(i), root))
+		}
+		return values
+	case reflect.Chan, reflect.Func:
+		return nil
+	}
+	return v.Interface()
+}
+
+// fields adds the exported fields of a struct to values, reading each field's tags as Populate does
+func (d dumper) fields(v reflect.Value, values, root map[string]interface{}) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		tag := structs.ReadFieldTag(field)
+		if len(field.PkgPath) > 0 || tag.Skip {
+			continue
+		}
+
+		target, key := values, field.Name
+		switch {
+		case len(tag.Src) > 0:
+			target, key = root, tag.Src[0]
+		case len(tag.Name) > 0:
+			key = tag.Name
+		}
+
+		// a secret field is never walked, as the fields within it could otherwise place its values at the root
+		if structs.IsEnabled(field.Tag.Get("secret")) {
+			target[key] = structs.Redacted
+			continue
+		}
+
+		val := d.structure(v.Field(i), root)
+		if nested, ok := val.(map[string]interface{}); ok && tag.Inline {
+			for key, val := range nested {
+				values[key] = val
+			}
+			continue
+		}
+		if val != nil {
+			target[key] = val
+		}
+	}
+}
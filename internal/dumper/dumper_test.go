@@ -0,0 +1,189 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package dumper
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	dotenvParser "github.com/driscollos/config/internal/sourcer/dotenv-parser"
+	"github.com/driscollos/config/internal/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Unit Tests")
+}
+
+var _ = Describe("Dumper", func() {
+	var (
+		myDumper dumper
+		values   map[string]interface{}
+		output   *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		myDumper = dumper{envPrefix: "MYAPP_"}
+		values = map[string]interface{}{
+			"Name": "my app",
+			"Db": map[string]interface{}{
+				"Host": "localhost",
+				"Port": 5432,
+			},
+			"Servers": []interface{}{"a", "b"},
+		}
+		output = &bytes.Buffer{}
+	})
+
+	Context("dumping values", func() {
+		When("yaml is requested", func() {
+			It("should write nested yaml", func() {
+				Expect(myDumper.Dump(output, values, structs.DumpYAML)).To(Succeed())
+				Expect(output.String()).To(Equal("Db:\n  Host: localhost\n  Port: 5432\nName: my app\nServers:\n  - a\n  - b\n"))
+			})
+		})
+
+		When("json is requested", func() {
+			It("should write indented json", func() {
+				Expect(myDumper.Dump(output, values, structs.DumpJSON)).To(Succeed())
+				Expect(output.String()).To(ContainSubstring(`"Port": 5432`))
+				Expect(output.String()).To(ContainSubstring(`"Servers": [`))
+			})
+		})
+
+		When("env is requested", func() {
+			It("should write sorted KEY=value lines, quoting values where needed", func() {
+				values["Password"] = "it's a $ecret"
+				values["Greeting"] = "hello $USER"
+				Expect(myDumper.Dump(output, values, structs.DumpEnv)).To(Succeed())
+				Expect(output.String()).To(Equal(`MYAPP_DB_HOST=localhost
+MYAPP_DB_PORT=5432
+MYAPP_GREETING='hello $USER'
+MYAPP_NAME='my app'
+MYAPP_PASSWORD="it's a \$ecret"
+MYAPP_SERVERS_0=a
+MYAPP_SERVERS_1=b
+`))
+			})
+
+			It("should write values which a dotenv file reads back unchanged", func() {
+				tricky := map[string]interface{}{
+					"Password": "it's a $ecret",
+					"Command":  "echo `whoami` \\ \"${HOME}\"",
+					"Cert":     "-----BEGIN-----\n\tabc $1\r\n-----END-----",
+					"Greeting": "hello $USER # not a comment",
+					"Blank":    "",
+				}
+				Expect(myDumper.Dump(output, map[string]interface{}{"Tricky": tricky}, structs.DumpEnv)).To(Succeed())
+
+				parsed, err := dotenvParser.New().Parse(output.Bytes())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(parsed).To(Equal(map[string]interface{}{
+					"MYAPP_TRICKY_PASSWORD": tricky["Password"],
+					"MYAPP_TRICKY_COMMAND":  tricky["Command"],
+					"MYAPP_TRICKY_CERT":     tricky["Cert"],
+					"MYAPP_TRICKY_GREETING": tricky["Greeting"],
+					"MYAPP_TRICKY_BLANK":    tricky["Blank"],
+				}))
+			})
+		})
+
+		When("an unknown format is requested", func() {
+			It("should return an error", func() {
+				Expect(myDumper.Dump(output, values, "xml")).To(MatchError("unknown dump format : xml"))
+			})
+		})
+	})
+
+	Context("converting a struct", func() {
+		type Connection struct {
+			Host string `yaml:"host"`
+		}
+
+		When("a populated struct is given", func() {
+			It("should key its values by the names Populate reads them from", func() {
+				port := 5432
+				myStruct := struct {
+					Connection `yaml:",inline"`
+					Port       *int
+					Timeout    time.Duration
+					Password   string            `yaml:"-"`
+					Tags       map[string]string `json:"labels"`
+					Region     string            `src:"Deploy_Region" yaml:"region"`
+					Servers    []string
+					Missing    *int
+					internal   string
+				}{
+					Connection: Connection{Host: "localhost"},
+					Port:       &port,
+					Timeout:    5 * time.Second,
+					Password:   "secret",
+					Tags:       map[string]string{"team": "accounts"},
+					Region:     "eu-west-1",
+					Servers:    []string{"a"},
+					internal:   "hidden",
+				}
+
+				structure, err := myDumper.Structure(&myStruct)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(structure).To(Equal(map[string]interface{}{
+					"host":          "localhost",
+					"Port":          5432,
+					"Timeout":       "5s",
+					"labels":        map[string]interface{}{"team": "accounts"},
+					"Deploy_Region": "eu-west-1",
+					"Servers":       []interface{}{"a"},
+				}))
+			})
+		})
+
+		When("the struct holds secrets", func() {
+			It("should redact them", func() {
+				myStruct := struct {
+					Password string `secret:"true" yaml:"password"`
+					Token    structs.Secret
+					Database struct {
+						Key string `src:"Db_Key" secret:"yes"`
+					}
+				}{Password: "hunter2", Token: "abc123"}
+				myStruct.Database.Key = "xyz"
+
+				structure, err := myDumper.Structure(myStruct)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(structure).To(Equal(map[string]interface{}{
+					"password": "[REDACTED]",
+					"Token":    "[REDACTED]",
+					"Database": map[string]interface{}{},
+					"Db_Key":   "[REDACTED]",
+				}))
+			})
+
+			It("should not reveal the values held by a secret struct", func() {
+				myStruct := struct {
+					Database struct {
+						Password string `src:"Db_Password"`
+					} `secret:"true"`
+				}{}
+				myStruct.Database.Password = "hunter2"
+
+				structure, err := myDumper.Structure(myStruct)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(structure).To(Equal(map[string]interface{}{
+					"Database": "[REDACTED]",
+				}))
+			})
+		})
+
+		When("something other than a struct is given", func() {
+			It("should return an error", func() {
+				_, err := myDumper.Structure("text")
+				Expect(err).To(MatchError(ErrorNotStruct))
+			})
+		})
+	})
+})
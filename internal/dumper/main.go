@@ -0,0 +1,12 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package dumper
+
+// New creates a Dumper. The prefix is added to the names of variables written in the env format
+func New(envPrefix string) Dumper {
+	return dumper{
+		envPrefix: envPrefix,
+	}
+}
@@ -5,8 +5,10 @@
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
 
+	structs "github.com/driscollos/config/internal/structs"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -33,18 +35,167 @@ func (m *MockSourcer) EXPECT() *MockSourcerMockRecorder {
 	return m.recorder
 }
 
+// Default mocks base method.
+func (m *MockSourcer) Default(arg0, arg1 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Default", arg0, arg1)
+}
+
+// Default indicates an expected call of Default.
+func (mr *MockSourcerMockRecorder) Default(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Default", reflect.TypeOf((*MockSourcer)(nil).Default), arg0, arg1)
+}
+
+// Err mocks base method.
+func (m *MockSourcer) Err() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Err")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Err indicates an expected call of Err.
+func (mr *MockSourcerMockRecorder) Err() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Err", reflect.TypeOf((*MockSourcer)(nil).Err))
+}
+
+// Explain mocks base method.
+func (m *MockSourcer) Explain(arg0 string) structs.Explanation {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Explain", arg0)
+	ret0, _ := ret[0].(structs.Explanation)
+	return ret0
+}
+
+// Explain indicates an expected call of Explain.
+func (mr *MockSourcerMockRecorder) Explain(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Explain", reflect.TypeOf((*MockSourcer)(nil).Explain), arg0)
+}
+
 // Get mocks base method.
-func (m *MockSourcer) Get(arg0 string) string {
+func (m *MockSourcer) Get(arg0 string, arg1 ...structs.LookupOptions) string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Get", arg0)
+	varargs := []interface{}{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Get", varargs...)
 	ret0, _ := ret[0].(string)
 	return ret0
 }
 
 // Get indicates an expected call of Get.
-func (mr *MockSourcerMockRecorder) Get(arg0 interface{}) *gomock.Call {
+func (mr *MockSourcerMockRecorder) Get(arg0 interface{}, arg1 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockSourcer)(nil).Get), varargs...)
+}
+
+// LookupOptions mocks base method.
+func (m *MockSourcer) LookupOptions(arg0 string, arg1 structs.LookupOptions) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "LookupOptions", arg0, arg1)
+}
+
+// LookupOptions indicates an expected call of LookupOptions.
+func (mr *MockSourcerMockRecorder) LookupOptions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LookupOptions", reflect.TypeOf((*MockSourcer)(nil).LookupOptions), arg0, arg1)
+}
+
+// OnChange mocks base method.
+func (m *MockSourcer) OnChange(arg0 func([]string)) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "OnChange", arg0)
+}
+
+// OnChange indicates an expected call of OnChange.
+func (mr *MockSourcerMockRecorder) OnChange(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnChange", reflect.TypeOf((*MockSourcer)(nil).OnChange), arg0)
+}
+
+// OnError mocks base method.
+func (m *MockSourcer) OnError(arg0 func(error)) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "OnError", arg0)
+}
+
+// OnError indicates an expected call of OnError.
+func (mr *MockSourcerMockRecorder) OnError(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnError", reflect.TypeOf((*MockSourcer)(nil).OnError), arg0)
+}
+
+// Origin mocks base method.
+func (m *MockSourcer) Origin(arg0 string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Origin", arg0)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Origin indicates an expected call of Origin.
+func (mr *MockSourcerMockRecorder) Origin(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Origin", reflect.TypeOf((*MockSourcer)(nil).Origin), arg0)
+}
+
+// Provenance mocks base method.
+func (m *MockSourcer) Provenance() map[string]structs.Explanation {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Provenance")
+	ret0, _ := ret[0].(map[string]structs.Explanation)
+	return ret0
+}
+
+// Provenance indicates an expected call of Provenance.
+func (mr *MockSourcerMockRecorder) Provenance() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockSourcer)(nil).Get), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Provenance", reflect.TypeOf((*MockSourcer)(nil).Provenance))
+}
+
+// Redact mocks base method.
+func (m *MockSourcer) Redact(arg0 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Redact", arg0)
+}
+
+// Redact indicates an expected call of Redact.
+func (mr *MockSourcerMockRecorder) Redact(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Redact", reflect.TypeOf((*MockSourcer)(nil).Redact), arg0)
+}
+
+// Reload mocks base method.
+func (m *MockSourcer) Reload() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reload")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reload indicates an expected call of Reload.
+func (mr *MockSourcerMockRecorder) Reload() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reload", reflect.TypeOf((*MockSourcer)(nil).Reload))
+}
+
+// Separator mocks base method.
+func (m *MockSourcer) Separator() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Separator")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Separator indicates an expected call of Separator.
+func (mr *MockSourcerMockRecorder) Separator() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Separator", reflect.TypeOf((*MockSourcer)(nil).Separator))
 }
 
 // Source mocks base method.
@@ -58,3 +209,67 @@ func (mr *MockSourcerMockRecorder) Source(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Source", reflect.TypeOf((*MockSourcer)(nil).Source), arg0)
 }
+
+// SourceFiles mocks base method.
+func (m *MockSourcer) SourceFiles(arg0 []structs.SourceFile) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SourceFiles", arg0)
+}
+
+// SourceFiles indicates an expected call of SourceFiles.
+func (mr *MockSourcerMockRecorder) SourceFiles(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SourceFiles", reflect.TypeOf((*MockSourcer)(nil).SourceFiles), arg0)
+}
+
+// UseOverrides mocks base method.
+func (m *MockSourcer) UseOverrides(arg0 bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UseOverrides", arg0)
+}
+
+// UseOverrides indicates an expected call of UseOverrides.
+func (mr *MockSourcerMockRecorder) UseOverrides(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseOverrides", reflect.TypeOf((*MockSourcer)(nil).UseOverrides), arg0)
+}
+
+// Validate mocks base method.
+func (m *MockSourcer) Validate(arg0 structs.Validator) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Validate", arg0)
+}
+
+// Validate indicates an expected call of Validate.
+func (mr *MockSourcerMockRecorder) Validate(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Validate", reflect.TypeOf((*MockSourcer)(nil).Validate), arg0)
+}
+
+// Values mocks base method.
+func (m *MockSourcer) Values() map[string]interface{} {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Values")
+	ret0, _ := ret[0].(map[string]interface{})
+	return ret0
+}
+
+// Values indicates an expected call of Values.
+func (mr *MockSourcerMockRecorder) Values() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Values", reflect.TypeOf((*MockSourcer)(nil).Values))
+}
+
+// Watch mocks base method.
+func (m *MockSourcer) Watch(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Watch", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Watch indicates an expected call of Watch.
+func (mr *MockSourcerMockRecorder) Watch(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Watch", reflect.TypeOf((*MockSourcer)(nil).Watch), arg0)
+}
@@ -0,0 +1,47 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/driscollos/config/internal/sourcer/file-watcher (interfaces: FileWatcher)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockFileWatcher is a mock of FileWatcher interface.
+type MockFileWatcher struct {
+	ctrl     *gomock.Controller
+	recorder *MockFileWatcherMockRecorder
+}
+
+// MockFileWatcherMockRecorder is the mock recorder for MockFileWatcher.
+type MockFileWatcherMockRecorder struct {
+	mock *MockFileWatcher
+}
+
+// NewMockFileWatcher creates a new mock instance.
+func NewMockFileWatcher(ctrl *gomock.Controller) *MockFileWatcher {
+	mock := &MockFileWatcher{ctrl: ctrl}
+	mock.recorder = &MockFileWatcherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFileWatcher) EXPECT() *MockFileWatcherMockRecorder {
+	return m.recorder
+}
+
+// Watch mocks base method.
+func (m *MockFileWatcher) Watch(arg0 context.Context, arg1 func() []string, arg2 func()) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Watch", arg0, arg1, arg2)
+}
+
+// Watch indicates an expected call of Watch.
+func (mr *MockFileWatcherMockRecorder) Watch(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Watch", reflect.TypeOf((*MockFileWatcher)(nil).Watch), arg0, arg1, arg2)
+}
@@ -8,4 +8,6 @@ const (
 	ErrorNotPointer           = "please supply a pointer to Populate()"
 	ErrorMissingRequiredValue = "missing required value : %s"
 	ErrorSourceIsBlank        = "source is blank"
+	NamingNone                = "none"
+	WarningDeprecatedKey      = "config : %s is deprecated : %s"
 )
@@ -5,15 +5,37 @@
 package populator
 
 import (
+	"log"
+
 	durationParser "github.com/driscollos/config/internal/populator/duration-parser"
 	floatParser "github.com/driscollos/config/internal/populator/float-parser"
-	"github.com/driscollos/config/internal/sourcer"
+	nameConverter "github.com/driscollos/config/internal/populator/name-converter"
+	"github.com/driscollos/config/internal/structs"
 )
 
-func New(src sourcer.Sourcer) Populator {
+// Options customise the populator returned by New
+type Options struct {
+	// Naming lists the conventions tried after the field name itself when looking up a field. If it is empty only the
+	// field name is tried, unless a field chooses conventions of its own with the naming tag
+	Naming []structs.NamingConvention
+
+	// Logger receives warnings about deprecated keys. If it is nil the standard logger is used
+	Logger Logger
+}
+
+func New(src structs.Lookup, options Options) Populator {
+	logger := options.Logger
+	if logger == nil {
+		logger = log.Default()
+	}
+
 	return populator{
 		src:            src,
+		separator:      src.Separator(),
 		floatParser:    floatParser.New(),
 		durationParser: durationParser.New(),
+		nameConverter:  nameConverter.New(),
+		naming:         options.Naming,
+		logger:         logger,
 	}
 }
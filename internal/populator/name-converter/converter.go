@@ -0,0 +1,75 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package nameConverter
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/driscollos/config/internal/structs"
+)
+
+// NameConverter rewrites the name of a struct field in a naming convention, so MaxIdleConns becomes max_idle_conns in
+// snake case. Runs of capitals are kept together as one word, so HTTPServer becomes http_server
+type NameConverter interface {
+	Convert(name string, convention structs.NamingConvention) string
+}
+
+type converter struct{}
+
+func (c converter) Convert(name string, convention structs.NamingConvention) string {
+	words := c.words(name)
+	switch convention {
+	case structs.CamelCase:
+		for x := range words {
+			words[x] = strings.ToLower(words[x])
+			if x > 0 {
+				words[x] = strings.ToUpper(words[x][:1]) + words[x][1:]
+			}
+		}
+		return strings.Join(words, "")
+	case structs.KebabCase:
+		return strings.ToLower(strings.Join(words, "-"))
+	case structs.ScreamingSnakeCase:
+		return strings.ToUpper(strings.Join(words, "_"))
+	case structs.SnakeCase:
+		return strings.ToLower(strings.Join(words, "_"))
+	}
+	return name
+}
+
+// words splits a name into words at underscores, hyphens and changes of case
+func (c converter) words(name string) []string {
+	runes := []rune(name)
+	words := make([]string, 0)
+	start := 0
+	for x := 0; x <= len(runes); x++ {
+		if x == len(runes) || runes[x] == '_' || runes[x] == '-' {
+			if x > start {
+				words = append(words, string(runes[start:x]))
+			}
+			start = x + 1
+			continue
+		}
+		if x > start && c.startsWord(runes, x) {
+			words = append(words, string(runes[start:x]))
+			start = x
+		}
+	}
+	return words
+}
+
+// startsWord reports whether the capital at pos begins a new word - either it follows a lower case letter or a digit,
+// or it is the last capital in a run which is followed by lower case, as the S in HTTPServer
+func (c converter) startsWord(runes []rune, pos int) bool {
+	if !unicode.IsUpper(runes[pos]) {
+		return false
+	}
+	previous := runes[pos-1]
+	if unicode.IsLower(previous) || unicode.IsDigit(previous) {
+		return true
+	}
+	return unicode.IsUpper(previous) && pos+1 < len(runes) && unicode.IsLower(runes[pos+1])
+}
@@ -0,0 +1,68 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package nameConverter
+
+import (
+	"testing"
+
+	"github.com/driscollos/config/internal/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Unit Tests")
+}
+
+var _ = Describe("Name converter", func() {
+	var myConverter converter
+
+	BeforeEach(func() {
+		myConverter = converter{}
+	})
+
+	Context("sample names", func() {
+		When("each naming convention is used", func() {
+			It("should rewrite the names correctly", func() {
+				for name, expected := range map[string]map[structs.NamingConvention]string{
+					"MaxIdleConns": {
+						structs.SnakeCase:          "max_idle_conns",
+						structs.KebabCase:          "max-idle-conns",
+						structs.CamelCase:          "maxIdleConns",
+						structs.ScreamingSnakeCase: "MAX_IDLE_CONNS",
+					},
+					"HTTPServer": {
+						structs.SnakeCase: "http_server",
+						structs.CamelCase: "httpServer",
+					},
+					"DBHost": {
+						structs.KebabCase: "db-host",
+					},
+					"Port": {
+						structs.SnakeCase: "port",
+						structs.CamelCase: "port",
+					},
+					"Retry3Times": {
+						structs.SnakeCase: "retry3_times",
+					},
+					"Already_Snake": {
+						structs.CamelCase: "alreadySnake",
+					},
+				} {
+					for convention, converted := range expected {
+						Expect(myConverter.Convert(name, convention)).To(Equal(converted), name)
+					}
+				}
+			})
+		})
+
+		When("the convention is not known", func() {
+			It("should return the name unchanged", func() {
+				Expect(myConverter.Convert("MaxIdleConns", "unknown")).To(Equal("MaxIdleConns"))
+			})
+		})
+	})
+})
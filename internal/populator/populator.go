@@ -15,7 +15,9 @@ import (
 
 	durationParser "github.com/driscollos/config/internal/populator/duration-parser"
 	floatParser "github.com/driscollos/config/internal/populator/float-parser"
+	nameConverter "github.com/driscollos/config/internal/populator/name-converter"
 	"github.com/driscollos/config/internal/sourcer"
+	"github.com/driscollos/config/internal/structs"
 )
 
 type Populator interface {
@@ -23,9 +25,18 @@ type Populator interface {
 }
 
 type populator struct {
-	src            sourcer.Sourcer
+	src            structs.Lookup
+	separator      string
 	floatParser    floatParser.FloatParser
 	durationParser durationParser.DurationParser
+	nameConverter  nameConverter.NameConverter
+	naming         []structs.NamingConvention
+	logger         Logger
+}
+
+// Logger receives warnings, such as a deprecated key being used. *log.Logger satisfies it
+type Logger interface {
+	Printf(format string, v ...interface{})
 }
 
 func (p populator) Populate(dest interface{}) error {
@@ -43,21 +54,57 @@ func (p populator) populate(t reflect.Type, v reflect.Value, prefix string) erro
 		f := v.Field(i)
 		ft := t.Field(i)
 
-		name := strings.Trim(fmt.Sprintf("%s_%s", prefix, t.Field(i).Name), "_")
-		if len(ft.Tag.Get("src")) > 0 {
-			name = ft.Tag.Get("src")
+		tag := structs.ReadFieldTag(ft)
+		if tag.Skip {
+			continue
+		}
+		if tag.Inline && ft.Type.Kind() == reflect.Struct {
+			if err := p.populate(ft.Type, f, prefix); err != nil {
+				return err
+			}
+			continue
+		}
+
+		options := make([]structs.LookupOptions, 0)
+		lookup := structs.LookupOptions{
+			Literal: structs.IsEnabled(ft.Tag.Get("literal")),
+			Env:     ft.Tag.Get("env"),
+			Flag:    ft.Tag.Get("flag"),
+			File:    ft.Tag.Get("file"),
+		}
+		if lookup != (structs.LookupOptions{}) {
+			options = append(options, lookup)
+		}
+
+		aliases := p.aliases(prefix, ft, tag)
+		names := append(p.names(prefix, ft, tag, lookup.Literal), aliases...)
+		if len(options) > 0 {
+			for _, name := range names {
+				p.src.LookupOptions(name, lookup)
+			}
+		}
+		if structs.IsEnabled(ft.Tag.Get("secret")) || ft.Type == reflect.TypeOf(structs.Secret("")) {
+			for _, name := range names {
+				p.src.Redact(name)
+			}
+			for _, name := range []string{lookup.Env, lookup.Flag, lookup.File} {
+				if len(name) > 0 {
+					p.src.Redact(name)
+				}
+			}
 		}
 
-		value := p.src.Get(name)
+		name, value := p.lookup(names, options)
 		if len(value) < 1 {
 			value = ft.Tag.Get("default")
+			if len(value) > 0 {
+				p.src.Default(name, value)
+			}
+		} else {
+			p.warnIfDeprecated(ft, name, aliases)
 		}
 
-		isRequired := false
-		switch strings.ToLower(ft.Tag.Get("required")) {
-		case "yes", "1", "true", "on":
-			isRequired = true
-		}
+		isRequired := structs.IsEnabled(ft.Tag.Get("required"))
 
 		if len(value) < 1 && isRequired {
 			return fmt.Errorf("missing required value : %s", name)
@@ -66,13 +113,13 @@ func (p populator) populate(t reflect.Type, v reflect.Value, prefix string) erro
 		switch ft.Type.Kind() {
 		case reflect.Map:
 			f.Set(reflect.MakeMap(ft.Type))
-			keys, err := p.findKeys(p.src.Get(name))
+			keys, err := p.findKeys(p.src.Get(name, options...))
 			if err == nil {
 				for _, key := range keys {
 					f.SetMapIndex(reflect.ValueOf(key), reflect.New(reflect.New(f.Type().Elem()).Elem().Type()).Elem())
 					if reflect.New(f.Type().Elem()).Elem().Kind() == reflect.Struct {
 						inner := reflect.New(reflect.New(f.Type().Elem()).Elem().Type()).Elem()
-						if err := p.populate(reflect.New(f.Type().Elem()).Elem().Type(), inner, fmt.Sprintf("%s_%s", name, key)); err != nil {
+						if err := p.populate(reflect.New(f.Type().Elem()).Elem().Type(), inner, p.join(name, sourcer.EscapeKey(key, p.separator))); err != nil {
 							return err
 						}
 						f.SetMapIndex(reflect.ValueOf(key), inner)
@@ -149,7 +196,7 @@ func (p populator) populate(t reflect.Type, v reflect.Value, prefix string) erro
 				}
 				for i := 0; i < sliceCount; i++ {
 					inner := reflect.New(reflect.New(f.Type().Elem()).Elem().Type()).Elem()
-					if err := p.populate(inner.Type(), inner, fmt.Sprintf("%s_%d", name, i)); err != nil {
+					if err := p.populate(inner.Type(), inner, p.join(name, strconv.Itoa(i))); err != nil {
 						return err
 					}
 					f.Set(reflect.Append(f, inner))
@@ -205,6 +252,96 @@ func (p populator) populate(t reflect.Type, v reflect.Value, prefix string) erro
 	return nil
 }
 
+// names lists the keys which may hold the value for a field, in the order they are tried. A src tag names the key
+// exactly, as does a yaml or json tag; otherwise the field name is tried as it is written and then in each naming
+// convention, which the naming tag can choose per field. Names which differ only in case are tried once, as keys are
+// matched regardless of case
+func (p populator) names(prefix string, ft reflect.StructField, tag structs.FieldTag, literal bool) []string {
+	if len(tag.Src) > 0 {
+		return []string{tag.Src[0]}
+	}
+	if len(tag.Name) > 0 {
+		return []string{p.join(prefix, sourcer.EscapeKey(tag.Name, p.separator))}
+	}
+
+	naming := p.naming
+	if conventions, exists := ft.Tag.Lookup("naming"); exists {
+		naming = make([]structs.NamingConvention, 0)
+		for _, convention := range structs.SplitTag(conventions) {
+			naming = append(naming, structs.NamingConvention(convention))
+		}
+	}
+
+	names := []string{p.join(prefix, ft.Name)}
+	for _, convention := range naming {
+		if convention == NamingNone {
+			continue
+		}
+		name := p.join(prefix, sourcer.EscapeKey(p.nameConverter.Convert(ft.Name, convention), p.separator))
+		if !p.contains(names, name, literal) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// aliases lists the older names of a field, which are tried once its own names have not been found. Any names after
+// the first in a src tag are aliases, as are the names in an alias tag - which, like field names, are relative to the
+// struct containing the field
+func (p populator) aliases(prefix string, ft reflect.StructField, tag structs.FieldTag) []string {
+	aliases := make([]string, 0)
+	if len(tag.Src) > 1 {
+		aliases = append(aliases, tag.Src[1:]...)
+	}
+	for _, alias := range structs.SplitTag(ft.Tag.Get("alias")) {
+		aliases = append(aliases, p.join(prefix, alias))
+	}
+	return aliases
+}
+
+// warnIfDeprecated reports a value read for a field tagged deprecated. Where the field has aliases only a value found
+// under one of them is reported, as the aliases are the names being retired
+func (p populator) warnIfDeprecated(ft reflect.StructField, name string, aliases []string) {
+	message, deprecated := ft.Tag.Lookup("deprecated")
+	if !deprecated || p.logger == nil {
+		return
+	}
+	if len(aliases) > 0 && !p.contains(aliases, name, true) {
+		return
+	}
+	p.logger.Printf(WarningDeprecatedKey, name, message)
+}
+
+func (p populator) contains(names []string, name string, literal bool) bool {
+	for _, existing := range names {
+		if existing == name || (!literal && strings.EqualFold(existing, name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns the first of the names which has a value. When none do the first name is used
+func (p populator) lookup(names []string, options []structs.LookupOptions) (string, string) {
+	for _, name := range names {
+		if value := p.src.Get(name, options...); len(value) > 0 {
+			return name, value
+		}
+	}
+	return names[0], ""
+}
+
+// join adds a level of nesting to a key
+func (p populator) join(prefix, name string) string {
+	if len(prefix) < 1 {
+		return name
+	}
+	if len(p.separator) < 1 {
+		return prefix + sourcer.DefaultSeparator + name
+	}
+	return prefix + p.separator + name
+}
+
 func (p populator) findKeys(src string) ([]string, error) {
 	if len(src) < 1 {
 		return nil, errors.New(ErrorSourceIsBlank)
@@ -5,15 +5,26 @@
 package populator
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/driscollos/config/internal/mocks"
 	floatParser "github.com/driscollos/config/internal/populator/float-parser"
+	nameConverter "github.com/driscollos/config/internal/populator/name-converter"
+	"github.com/driscollos/config/internal/structs"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
 
+type recordingLogger struct {
+	messages []string
+}
+
+func (r *recordingLogger) Printf(format string, v ...interface{}) {
+	r.messages = append(r.messages, fmt.Sprintf(format, v...))
+}
+
 func TestSuite(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Unit Tests")
@@ -56,6 +67,90 @@ var _ = Describe("Unit tests", func() {
 				Expect(err).ToNot(HaveOccurred())
 			})
 		})
+		When("a field is tagged literal", func() {
+			It("should ask the sourcer for an exact match", func() {
+				myStruct := struct {
+					Name string `literal:"true"`
+				}{}
+
+				mockSourcer.EXPECT().LookupOptions("Name", structs.LookupOptions{Literal: true})
+				mockSourcer.EXPECT().Get("Name", structs.LookupOptions{Literal: true}).Return("Bob")
+
+				err := myPopulator.Populate(&myStruct)
+				Expect(myStruct.Name).To(Equal("Bob"))
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+		When("a field names its env, flag and file keys", func() {
+			It("should pass the names to the sourcer alongside the shared name, and record them", func() {
+				myStruct := struct {
+					DatabaseUrl string `src:"Db" env:"DATABASE_URL" flag:"db-url" file:"database_url"`
+				}{}
+
+				lookup := structs.LookupOptions{
+					Env:  "DATABASE_URL",
+					Flag: "db-url",
+					File: "database_url",
+				}
+				mockSourcer.EXPECT().LookupOptions("Db", lookup)
+				mockSourcer.EXPECT().Get("Db", lookup).Return("postgres://")
+
+				err := myPopulator.Populate(&myStruct)
+				Expect(myStruct.DatabaseUrl).To(Equal("postgres://"))
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+		When("a field falls back to its default", func() {
+			It("should tell the sourcer the default was used", func() {
+				myStruct := struct {
+					Timeout string `default:"30s"`
+				}{}
+
+				mockSourcer.EXPECT().Get("Timeout").Return("")
+				mockSourcer.EXPECT().Default("Timeout", "30s")
+
+				err := myPopulator.Populate(&myStruct)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(myStruct.Timeout).To(Equal("30s"))
+			})
+		})
+		When("a field holds a secret", func() {
+			It("should ask the sourcer to redact every name the field is read from", func() {
+				myStruct := struct {
+					Password string `secret:"true" alias:"Pass"`
+					Token    structs.Secret
+				}{}
+
+				mockSourcer.EXPECT().Redact("Password")
+				mockSourcer.EXPECT().Redact("Pass")
+				mockSourcer.EXPECT().Redact("Token")
+				mockSourcer.EXPECT().Get("Password").Return("hunter2")
+				mockSourcer.EXPECT().Get("Token").Return("abc123")
+
+				err := myPopulator.Populate(&myStruct)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(myStruct.Password).To(Equal("hunter2"))
+				Expect(myStruct.Token.Value()).To(Equal("abc123"))
+			})
+
+			It("should also redact the names given for each kind of source", func() {
+				myStruct := struct {
+					DbPassword structs.Secret `env:"DB_PASS" flag:"db-pass" file:"Db_Password"`
+				}{}
+
+				lookup := structs.LookupOptions{Env: "DB_PASS", Flag: "db-pass", File: "Db_Password"}
+				mockSourcer.EXPECT().LookupOptions("DbPassword", lookup)
+				mockSourcer.EXPECT().Redact("DbPassword")
+				mockSourcer.EXPECT().Redact("DB_PASS")
+				mockSourcer.EXPECT().Redact("db-pass")
+				mockSourcer.EXPECT().Redact("Db_Password")
+				mockSourcer.EXPECT().Get("DbPassword", lookup).Return("hunter2")
+
+				err := myPopulator.Populate(&myStruct)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(myStruct.DbPassword.Value()).To(Equal("hunter2"))
+			})
+		})
 		When("a struct is provided with an int in it", func() {
 			It("should populate the field appropriately", func() {
 				myStruct := struct {
@@ -159,5 +254,220 @@ var _ = Describe("Unit tests", func() {
 				Expect(myStruct.Hobbies).To(Equal([]string{"Travel", "Adventure"}))
 			})
 		})
+		When("naming conventions are in use", func() {
+			BeforeEach(func() {
+				myPopulator.nameConverter = nameConverter.New()
+				myPopulator.naming = []structs.NamingConvention{structs.SnakeCase, structs.KebabCase, structs.CamelCase}
+			})
+
+			It("should try the field name and then each convention in turn", func() {
+				myStruct := struct {
+					MaxIdleConns int
+				}{}
+
+				mockSourcer.EXPECT().Get("MaxIdleConns").Return("")
+				mockSourcer.EXPECT().Get(`max\_idle\_conns`).Return("")
+				mockSourcer.EXPECT().Get("max-idle-conns").Return("5")
+
+				err := myPopulator.Populate(&myStruct)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(myStruct.MaxIdleConns).To(Equal(5))
+			})
+
+			It("should read nested fields beneath the name which matched", func() {
+				myStruct := struct {
+					DbPool struct {
+						Size int
+					}
+				}{}
+
+				mockSourcer.EXPECT().Get("DbPool").Return("")
+				mockSourcer.EXPECT().Get(`db\_pool`).Return(`"size":5`)
+				mockSourcer.EXPECT().Get(`db\_pool_Size`).Return("5")
+
+				err := myPopulator.Populate(&myStruct)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(myStruct.DbPool.Size).To(Equal(5))
+			})
+
+			It("should use the conventions chosen by the naming tag", func() {
+				myStruct := struct {
+					MaxIdleConns int `naming:"kebab"`
+					IdleTimeout  int `naming:"none"`
+				}{}
+
+				mockSourcer.EXPECT().Get("MaxIdleConns").Return("")
+				mockSourcer.EXPECT().Get("max-idle-conns").Return("")
+				mockSourcer.EXPECT().Get("IdleTimeout").Return("")
+
+				err := myPopulator.Populate(&myStruct)
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+		When("no naming conventions are chosen", func() {
+			It("should only try the field name, unless the naming tag asks for more", func() {
+				mockSourcer.EXPECT().Separator().Return("_")
+				myPopulator = New(mockSourcer, Options{}).(populator)
+				myStruct := struct {
+					MaxIdleConns int
+					IdleTimeout  int `naming:"snake"`
+				}{}
+
+				mockSourcer.EXPECT().Get("MaxIdleConns").Return("")
+				mockSourcer.EXPECT().Get("IdleTimeout").Return("")
+				mockSourcer.EXPECT().Get(`idle\_timeout`).Return("30")
+
+				err := myPopulator.Populate(&myStruct)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(myStruct.IdleTimeout).To(Equal(30))
+			})
+		})
+		When("fields have yaml or json tags", func() {
+			type Pool struct {
+				Size int `yaml:"pool_size"`
+			}
+
+			It("should read the names given by the tags", func() {
+				myStruct := struct {
+					MaxIdleConns int    `yaml:"max_idle_conns,omitempty" json:"maxIdle"`
+					Host         string `json:"host_name"`
+					Port         int    `yaml:",omitempty" json:"port"`
+					Name         string `src:"name" yaml:"title"`
+				}{}
+
+				mockSourcer.EXPECT().Get(`max\_idle\_conns`).Return("5")
+				mockSourcer.EXPECT().Get(`host\_name`).Return("localhost")
+				mockSourcer.EXPECT().Get("port").Return("80")
+				mockSourcer.EXPECT().Get("name").Return("Bob")
+
+				err := myPopulator.Populate(&myStruct)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(myStruct.MaxIdleConns).To(Equal(5))
+				Expect(myStruct.Host).To(Equal("localhost"))
+				Expect(myStruct.Port).To(Equal(80))
+				Expect(myStruct.Name).To(Equal("Bob"))
+			})
+
+			It("should skip fields tagged with a dash", func() {
+				myStruct := struct {
+					Password string `yaml:"-"`
+					Token    string `json:"-"`
+				}{}
+
+				err := myPopulator.Populate(&myStruct)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(myStruct.Password).To(BeEmpty())
+			})
+
+			It("should read inline fields at the level of their parent", func() {
+				myStruct := struct {
+					Db struct {
+						Pool `yaml:",inline"`
+					}
+				}{}
+
+				mockSourcer.EXPECT().Get("Db").Return(`"pool_size":5`)
+				mockSourcer.EXPECT().Get(`Db_pool\_size`).Return("5")
+
+				err := myPopulator.Populate(&myStruct)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(myStruct.Db.Size).To(Equal(5))
+			})
+		})
+		When("fields have aliases", func() {
+			var logger *recordingLogger
+
+			BeforeEach(func() {
+				logger = &recordingLogger{}
+				myPopulator.logger = logger
+			})
+
+			It("should try each name in the src tag in order", func() {
+				myStruct := struct {
+					Timeout int `src:"Timeout,OldTimeout"`
+				}{}
+
+				mockSourcer.EXPECT().Get("Timeout").Return("")
+				mockSourcer.EXPECT().Get("OldTimeout").Return("30")
+
+				err := myPopulator.Populate(&myStruct)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(myStruct.Timeout).To(Equal(30))
+			})
+
+			It("should read alias tags relative to the containing struct", func() {
+				myStruct := struct {
+					Db struct {
+						Host string `alias:"Hostname,Server"`
+					}
+				}{}
+
+				mockSourcer.EXPECT().Get("Db").Return(`"Server":"db.internal"`)
+				mockSourcer.EXPECT().Get("Db_Host").Return("")
+				mockSourcer.EXPECT().Get("Db_Hostname").Return("")
+				mockSourcer.EXPECT().Get("Db_Server").Return("db.internal")
+
+				err := myPopulator.Populate(&myStruct)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(myStruct.Db.Host).To(Equal("db.internal"))
+			})
+
+			It("should warn when a deprecated alias supplied the value", func() {
+				myStruct := struct {
+					Timeout int `alias:"OldTimeout" deprecated:"use Timeout"`
+				}{}
+
+				mockSourcer.EXPECT().Get("Timeout").Return("")
+				mockSourcer.EXPECT().Get("OldTimeout").Return("30")
+
+				err := myPopulator.Populate(&myStruct)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(logger.messages).To(Equal([]string{"config : OldTimeout is deprecated : use Timeout"}))
+			})
+
+			It("should not warn when the current name supplied the value", func() {
+				myStruct := struct {
+					Timeout int `alias:"OldTimeout" deprecated:"use Timeout"`
+				}{}
+
+				mockSourcer.EXPECT().Get("Timeout").Return("30")
+
+				err := myPopulator.Populate(&myStruct)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(logger.messages).To(BeEmpty())
+			})
+
+			It("should warn whenever a deprecated field without aliases is set", func() {
+				myStruct := struct {
+					Legacy string `deprecated:"no longer used"`
+				}{}
+
+				mockSourcer.EXPECT().Get("Legacy").Return("on")
+
+				err := myPopulator.Populate(&myStruct)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(logger.messages).To(Equal([]string{"config : Legacy is deprecated : no longer used"}))
+			})
+		})
+		When("a separator other than an underscore is used", func() {
+			It("should join nested names and escape map keys with the separator", func() {
+				myPopulator.separator = "."
+				myStruct := struct {
+					Db struct {
+						Pools map[string]struct {
+							Size int
+						}
+					}
+				}{}
+
+				mockSourcer.EXPECT().Get("Db").Return("")
+				mockSourcer.EXPECT().Get("Db.Pools").Return(`"read.only":{"Size":5}`).Times(2)
+				mockSourcer.EXPECT().Get(`Db.Pools.read\.only.Size`).Return("5")
+
+				err := myPopulator.Populate(&myStruct)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(myStruct.Db.Pools["read.only"].Size).To(Equal(5))
+			})
+		})
 	})
 })
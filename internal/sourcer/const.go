@@ -5,5 +5,14 @@
 package sourcer
 
 const (
-	ErrorUnknownFileFormat = "could not determine file format. Please use files that end either with .yml, .yaml or .json"
+	DefaultProfileVariable  = "APP_ENV"
+	DefaultSeparator        = "_"
+	ErrorAlreadyWatching    = "the configuration is already being watched"
+	ErrorIncludeCycle       = "include cycle detected : %s"
+	ErrorInterpolation      = "could not expand the value of %s : %s"
+	ErrorInterpolationCycle = "interpolation cycle detected : %s"
+	ErrorInvalidInclude     = "the %s directive must name a file or a list of files"
+	ErrorKeyConflict        = "conflicting keys : %s holds a value but %s is nested beneath it"
+	ErrorMissingInclude     = "could not read included file : %s : included by %s"
+	ErrorUnknownFileFormat  = "could not determine file format. Please use a known extension such as .yml, .json or .toml, or register your own format with RegisterFormat"
 )
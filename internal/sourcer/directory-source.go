@@ -0,0 +1,116 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package sourcer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	fileReader "github.com/driscollos/config/internal/sourcer/file-reader"
+)
+
+// directorySource reads a directory in which every file name is a key and its content is the value, as used by
+// kubernetes ConfigMaps and Secrets or docker secrets. Nested directories become nested keys, so db/password answers
+// Db_Password. File names are matched regardless of case
+type directorySource struct {
+	path      string
+	required  bool
+	reader    fileReader.FileReader
+	values    map[string]interface{}
+	locations map[string]interface{}
+}
+
+func (d directorySource) load() ([]Source, error) {
+	if _, err := os.Stat(d.path); err != nil {
+		if d.required {
+			return nil, fmt.Errorf("could not read from source directory : %s", d.path)
+		}
+		return nil, nil
+	}
+
+	values, locations, err := d.read(d.path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading source directory : %s : %s", d.path, err.Error())
+	}
+	d.values = values
+	d.locations = locations
+	return []Source{d}, nil
+}
+
+// read returns the values held in dir, alongside the file which holds each of them
+func (d directorySource) read(dir string) (map[string]interface{}, map[string]interface{}, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values := make(map[string]interface{})
+	locations := make(map[string]interface{})
+	for _, entry := range entries {
+		// kubernetes keeps the real files in hidden ..data directories and links to them, so hidden entries are skipped
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		key := entry.Name()
+		if info.IsDir() {
+			nested, nestedLocations, err := d.read(path)
+			if err != nil {
+				return nil, nil, err
+			}
+			values[key] = nested
+			locations[key] = nestedLocations
+			continue
+		}
+
+		bytes, err := d.reader.Read(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		values[key] = strings.TrimRight(string(bytes), "\r\n")
+		locations[key] = path
+	}
+	return values, locations, nil
+}
+
+func (d directorySource) Lookup(key string) (interface{}, bool) {
+	return d.lookupPath(newKeyPath(key, DefaultSeparator, false))
+}
+
+func (d directorySource) lookupPath(path keyPath) (interface{}, bool) {
+	val := walk(d.values, path)
+	return val, val != nil
+}
+
+func (d directorySource) locate(path keyPath) string {
+	if location, ok := walk(d.locations, path).(string); ok {
+		return location
+	}
+	return d.path
+}
+
+func (d directorySource) paths() [][]string {
+	return collectPaths(d.values, nil)
+}
+
+func (d directorySource) Keys() []string {
+	return joinPaths(d.paths())
+}
+
+func (d directorySource) Name() string {
+	return d.path
+}
+
+func (d directorySource) watched() []string {
+	return []string{d.path}
+}
@@ -0,0 +1,10 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package dotenvParser
+
+const (
+	ErrorInvalidLine       = "invalid line %d : expected KEY=value"
+	ErrorUnterminatedQuote = "unterminated quoted value for %s starting on line %d"
+)
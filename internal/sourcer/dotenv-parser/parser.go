@@ -0,0 +1,140 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package dotenvParser
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+type DotenvParser interface {
+	Parse(source []byte) (map[string]interface{}, error)
+}
+
+type parser struct{}
+
+func (p parser) Parse(source []byte) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	lines := strings.Split(strings.ReplaceAll(string(source), "\r\n", "\n"), "\n")
+
+	for x := 0; x < len(lines); x++ {
+		line := strings.TrimSpace(lines[x])
+		if len(line) < 1 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		pos := strings.Index(line, "=")
+		if pos < 1 {
+			return nil, fmt.Errorf(ErrorInvalidLine, x+1)
+		}
+		key := strings.TrimSpace(line[:pos])
+		value := strings.TrimSpace(line[pos+1:])
+
+		if len(value) < 1 || (value[0] != '"' && value[0] != '\'') {
+			if comment := strings.Index(value, " #"); comment > -1 {
+				value = strings.TrimSpace(value[:comment])
+			}
+			values[key] = p.expand(value, values, false)
+			continue
+		}
+
+		quote := value[0]
+		start := x
+		body := value[1:]
+		end := p.closingQuote(body, quote)
+		for end < 0 {
+			x++
+			if x >= len(lines) {
+				return nil, fmt.Errorf(ErrorUnterminatedQuote, key, start+1)
+			}
+			body += "\n" + lines[x]
+			end = p.closingQuote(body, quote)
+		}
+
+		if quote == '\'' {
+			values[key] = body[:end]
+			continue
+		}
+		values[key] = p.expand(body[:end], values, true)
+	}
+	return values, nil
+}
+
+// closingQuote finds the position of the quote which terminates a value, ignoring quotes escaped with a backslash
+// inside double quoted values. It returns -1 if the value is not yet terminated
+func (p parser) closingQuote(body string, quote byte) int {
+	for x := 0; x < len(body); x++ {
+		if quote == '"' && body[x] == '\\' {
+			x++
+			continue
+		}
+		if body[x] == quote {
+			return x
+		}
+	}
+	return -1
+}
+
+// expand replaces ${VAR} and $VAR references with values defined earlier in the file or, failing that, with
+// environment variables. Escape sequences are only honoured inside double quoted values
+func (p parser) expand(value string, values map[string]interface{}, escapes bool) string {
+	var result strings.Builder
+	for x := 0; x < len(value); x++ {
+		c := value[x]
+		if escapes && c == '\\' && x+1 < len(value) {
+			x++
+			switch value[x] {
+			case 'n':
+				result.WriteByte('\n')
+			case 't':
+				result.WriteByte('\t')
+			case 'r':
+				result.WriteByte('\r')
+			default:
+				result.WriteByte(value[x])
+			}
+			continue
+		}
+		if c != '$' || x+1 >= len(value) {
+			result.WriteByte(c)
+			continue
+		}
+
+		name := ""
+		if value[x+1] == '{' {
+			end := strings.Index(value[x:], "}")
+			if end < 0 {
+				result.WriteByte(c)
+				continue
+			}
+			name = value[x+2 : x+end]
+			x += end
+		} else {
+			end := x + 1
+			for end < len(value) && p.isNameChar(value[end]) {
+				end++
+			}
+			if end == x+1 {
+				result.WriteByte(c)
+				continue
+			}
+			name = value[x+1 : end]
+			x = end - 1
+		}
+
+		if val, exists := values[name]; exists {
+			result.WriteString(fmt.Sprintf("%v", val))
+			continue
+		}
+		result.WriteString(os.Getenv(name))
+	}
+	return result.String()
+}
+
+func (p parser) isNameChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
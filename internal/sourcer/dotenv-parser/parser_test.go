@@ -0,0 +1,70 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package dotenvParser
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"os"
+	"testing"
+)
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Unit Tests")
+}
+
+var _ = Describe("Dotenv parser", func() {
+	var myParser parser
+
+	BeforeEach(func() {
+		myParser = parser{}
+	})
+
+	Context("sample files", func() {
+		When("various forms are used", func() {
+			It("should parse the file correctly", func() {
+				os.Setenv("DOTENV_TEST_HOST", "localhost")
+				defer os.Unsetenv("DOTENV_TEST_HOST")
+				values, err := myParser.Parse([]byte(`
+# database settings
+DB_USER=bob
+export DB_NAME = accounts # trailing comment
+DB_PASS='pa$$word # not a comment'
+DB_URL="postgres://${DB_USER}@$DOTENV_TEST_HOST/${DB_NAME}"
+GREETING="Hello\n\"World\""
+CERT="-----BEGIN-----
+abc
+-----END-----"
+EMPTY=
+`))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(values).To(Equal(map[string]interface{}{
+					"DB_USER":  "bob",
+					"DB_NAME":  "accounts",
+					"DB_PASS":  "pa$$word # not a comment",
+					"DB_URL":   "postgres://bob@localhost/accounts",
+					"GREETING": "Hello\n\"World\"",
+					"CERT":     "-----BEGIN-----\nabc\n-----END-----",
+					"EMPTY":    "",
+				}))
+			})
+		})
+
+		When("a line is not a KEY=value pair", func() {
+			It("should return an error", func() {
+				_, err := myParser.Parse([]byte("NAME=bob\nnonsense\n"))
+				Expect(err).To(MatchError("invalid line 2 : expected KEY=value"))
+			})
+		})
+
+		When("a quoted value is never terminated", func() {
+			It("should return an error", func() {
+				_, err := myParser.Parse([]byte("NAME=\"bob\nAGE=41\n"))
+				Expect(err).To(MatchError("unterminated quoted value for NAME starting on line 1"))
+			})
+		})
+	})
+})
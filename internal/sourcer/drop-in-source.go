@@ -0,0 +1,77 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package sourcer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	fileReader "github.com/driscollos/config/internal/sourcer/file-reader"
+)
+
+// dropInSource reads every configuration file in a conf.d style directory. Fragments are merged in lexical order of
+// their names, so 20-database.yml overrides 10-defaults.yml. Each fragment loads as its own file source, which means
+// the fragment that supplied a value can be identified by its name
+type dropInSource struct {
+	path     string
+	required bool
+	reader   fileReader.FileReader
+}
+
+func (d dropInSource) load() ([]Source, error) {
+	entries, err := os.ReadDir(d.path)
+	if err != nil {
+		if d.required {
+			return nil, fmt.Errorf("could not read from source directory : %s", d.path)
+		}
+		return nil, nil
+	}
+
+	names := make([]string, 0)
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if _, known := decoderFor(fileFormat(entry.Name())); !known {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	fragments := make([]Source, 0)
+	for x := len(names) - 1; x >= 0; x-- {
+		fragment := fileSource{
+			path:     filepath.Join(d.path, names[x]),
+			required: true,
+			reader:   d.reader,
+		}
+		loaded, err := fragment.load()
+		if err != nil {
+			return nil, err
+		}
+		fragments = append(fragments, loaded...)
+	}
+	return fragments, nil
+}
+
+func (d dropInSource) Lookup(key string) (interface{}, bool) {
+	return nil, false
+}
+
+func (d dropInSource) Keys() []string {
+	return nil
+}
+
+func (d dropInSource) Name() string {
+	return d.path
+}
+
+func (d dropInSource) watched() []string {
+	return []string{d.path}
+}
@@ -0,0 +1,78 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package sourcer
+
+import (
+	"os"
+	"strings"
+)
+
+// environmentSource reads environment variables. Names are matched regardless of case, so Name is answered by NAME.
+// When a prefix is set only variables starting with it are considered, and the prefix is not part of the key - with
+// the prefix MYAPP_ the key Port is answered by MYAPP_PORT
+type environmentSource struct {
+	prefix string
+}
+
+func (e environmentSource) Lookup(key string) (interface{}, bool) {
+	return e.lookupPath(newKeyPath(key, DefaultSeparator, false))
+}
+
+// lookupPath reads the variable named by the path, where each level of nesting is joined by an underscore
+func (e environmentSource) lookupPath(path keyPath) (interface{}, bool) {
+	if _, val, found := e.find(path); found {
+		return val, true
+	}
+	return nil, false
+}
+
+func (e environmentSource) locate(path keyPath) string {
+	name, _, _ := e.find(path)
+	return "$" + name
+}
+
+func (e environmentSource) paths() [][]string {
+	paths := make([][]string, 0)
+	for _, key := range e.Keys() {
+		paths = append(paths, []string{key})
+	}
+	return paths
+}
+
+// find returns the name and value of the variable which answers path, matching the name regardless of case unless the
+// path is literal
+func (e environmentSource) find(path keyPath) (string, string, bool) {
+	name := e.prefix + path.variable()
+	if val := os.Getenv(name); len(val) > 0 {
+		return name, val, true
+	}
+	if path.literal {
+		return "", "", false
+	}
+
+	for _, pair := range os.Environ() {
+		bits := strings.SplitN(pair, "=", 2)
+		if len(bits) == 2 && len(bits[1]) > 0 && strings.EqualFold(bits[0], name) {
+			return bits[0], bits[1], true
+		}
+	}
+	return "", "", false
+}
+
+func (e environmentSource) Keys() []string {
+	keys := make([]string, 0)
+	for _, pair := range os.Environ() {
+		name := strings.SplitN(pair, "=", 2)[0]
+		if len(name) <= len(e.prefix) || !strings.EqualFold(name[:len(e.prefix)], e.prefix) {
+			continue
+		}
+		keys = append(keys, name[len(e.prefix):])
+	}
+	return keys
+}
+
+func (e environmentSource) Name() string {
+	return "environment"
+}
@@ -0,0 +1,323 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package sourcer
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/driscollos/config/internal/sourcer/interpolator"
+	"github.com/driscollos/config/internal/structs"
+)
+
+// Default records that a struct default was used for path, so that it can be reported by Explain
+func (s *sourcer) Default(path, value string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.defaults == nil {
+		s.defaults = make(map[string]string)
+	}
+	s.defaults[path] = value
+}
+
+// LookupOptions records the options a struct field gave for path, so that Explain, Provenance and Values look the
+// value up the same way
+func (s *sourcer) LookupOptions(path string, options structs.LookupOptions) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.lookups == nil {
+		s.lookups = make(map[string]structs.LookupOptions)
+	}
+	s.lookups[path] = options
+}
+
+// Redact marks path as a secret, so that its value is never shown by Explain, Provenance or Values. Nor is the value
+// of any key which refers to path, as expanding the reference would show the secret
+func (s *sourcer) Redact(path string) {
+	key := s.secretKey(path)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.secrets == nil {
+		s.secrets = make(map[string]bool)
+	}
+	s.secrets[key] = true
+}
+
+func (s *sourcer) isSecret(path string) bool {
+	key := s.secretKey(path)
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.secrets[key]
+}
+
+// secretKey reduces a path to the form of an environment variable, so that Db_Password and a top level db_password
+// are treated as the same secret however they are split into levels. Treating too much as secret is always safe
+func (s *sourcer) secretKey(path string) string {
+	return strings.ToLower(newKeyPath(path, s.separator, false).variable())
+}
+
+// references follows the references in a value as it was written, reporting whether any of them names a secret, as
+// expanding the value would show the secret. Keys already being followed are not followed again, so references which
+// loop back on themselves end the search
+func (s *sourcer) references(written []Source, value string, expands bool, following []string) bool {
+	if !expands || !strings.Contains(value, "${") {
+		return false
+	}
+
+	found := false
+	interpolator.New().Expand(value, func(name string) (string, error) {
+		for _, key := range following {
+			if key == name {
+				return "", nil
+			}
+		}
+		if s.isSecret(name) {
+			found = true
+			return "", nil
+		}
+
+		val, layer := s.find(written, name, structs.LookupOptions{})
+		chain := append(append(make([]string, 0, len(following)+1), following...), name)
+		if s.references(written, format(val), s.expands(layer, name, structs.LookupOptions{}), chain) {
+			found = true
+		}
+		return "", nil
+	})
+	return found
+}
+
+// Explain describes where the value for path came from. Every source which holds a value for path is listed, the
+// first of them supplying the value and the others overridden by it. A struct default is the lowest priority of all.
+// Each source's value is given as it was written, and the value used with its references resolved
+func (s *sourcer) Explain(path string) structs.Explanation {
+	return s.explain(s.latest(), path)
+}
+
+func (s *sourcer) explain(current *state, path string) structs.Explanation {
+	explanation := structs.Explanation{Key: path}
+	candidates := make([]structs.Candidate, 0)
+	options := s.optionsFor(path)
+	refersToSecret := false
+	var resolved interface{}
+
+	for x, layer := range current.written {
+		val, found := s.lookup(layer, path, options)
+		if !found {
+			continue
+		}
+		if len(candidates) < 1 {
+			resolved = s.resolvedValue(current, x, path, options, val)
+			refersToSecret = s.references(current.written, format(val), s.expands(layer, path, options), []string{path})
+		}
+		candidates = append(candidates, structs.Candidate{
+			Name:     layer.Name(),
+			Location: s.locate(layer, path, options),
+			Value:    format(val),
+		})
+	}
+	if val, found := s.defaultFor(path); found {
+		candidates = append(candidates, structs.Candidate{Name: "default", Location: "default", Value: val})
+	}
+
+	if len(candidates) < 1 {
+		return explanation
+	}
+	explanation.Source = &candidates[0]
+	explanation.Overridden = candidates[1:]
+	explanation.Value = explanation.Source.Value
+	if resolved != nil {
+		explanation.Value = format(resolved)
+	}
+
+	switch {
+	case s.isSecret(path):
+		explanation.Value = structs.Redacted
+		for x := range candidates {
+			candidates[x].Value = structs.Redacted
+		}
+	case refersToSecret:
+		explanation.Value = structs.Redacted
+	}
+	return explanation
+}
+
+// Provenance explains every key held by the sources, other than the environment, and every key given a struct
+// default. Environment variables are reported where they supply or override one of those keys
+func (s *sourcer) Provenance() map[string]structs.Explanation {
+	provenance := make(map[string]structs.Explanation)
+	current := s.latest()
+	for _, key := range s.keys(current.layers) {
+		provenance[key] = s.explain(current, key)
+	}
+	return provenance
+}
+
+// Values gathers the value of every key reported by Provenance into nested maps, giving the merged view of every
+// source. Values read from files keep their types, references in text values from files are expanded and secrets are
+// redacted
+func (s *sourcer) Values() map[string]interface{} {
+	values := make(map[string]interface{})
+	current := s.latest()
+	for _, key := range s.keys(current.layers) {
+		val, found := s.value(current, key)
+		if !found {
+			continue
+		}
+		if s.isSecret(key) {
+			val = structs.Redacted
+		}
+		insert(values, newKeyPath(key, s.separator, false).segments, val)
+	}
+
+	for key, val := range values {
+		values[key] = listify(val)
+	}
+	return values
+}
+
+func (s *sourcer) value(current *state, path string) (interface{}, bool) {
+	options := s.optionsFor(path)
+	for x, layer := range current.written {
+		written, found := s.lookup(layer, path, options)
+		if !found {
+			continue
+		}
+		text, isText := written.(string)
+		if isText && s.references(current.written, text, s.expands(layer, path, options), []string{path}) {
+			return structs.Redacted, true
+		}
+		return s.resolvedValue(current, x, path, options, written), true
+	}
+	return s.defaultFor(path)
+}
+
+// resolvedValue gives the value the layer at position x holds for path once its references are resolved, given the
+// value as it was written. Only configuration files hold resolved values of their own
+func (s *sourcer) resolvedValue(current *state, x int, path string, options structs.LookupOptions, written interface{}) interface{} {
+	if _, ok := current.written[x].(fileSource); !ok {
+		return written
+	}
+	val, _ := s.lookup(current.layers[x], path, options)
+	return val
+}
+
+// insert places val at the nested position named by segments. Where one source holds a single value and another
+// holds values nested beneath the same key, the nested values are kept
+func insert(values map[string]interface{}, segments []string, val interface{}) {
+	key := segments[0]
+	for existing := range values {
+		if strings.EqualFold(existing, key) {
+			key = existing
+			break
+		}
+	}
+
+	if len(segments) == 1 {
+		if _, isMap := values[key].(map[string]interface{}); !isMap {
+			values[key] = val
+		}
+		return
+	}
+
+	nested, isMap := values[key].(map[string]interface{})
+	if !isMap {
+		nested = make(map[string]interface{})
+		values[key] = nested
+	}
+	insert(nested, segments[1:], val)
+}
+
+// keys lists the keys held by every source other than the environment, whose variables are mostly unrelated to the
+// configuration. Keys which differ only in case are listed once
+func (s *sourcer) keys(layers []Source) []string {
+	keys := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(key string) {
+		if !seen[strings.ToLower(key)] {
+			seen[strings.ToLower(key)] = true
+			keys = append(keys, key)
+		}
+	}
+
+	for _, layer := range layers {
+		switch typed := layer.(type) {
+		case environmentSource:
+			continue
+		case pathSource:
+			for _, path := range typed.paths() {
+				escaped := make([]string, 0, len(path))
+				for _, segment := range path {
+					escaped = append(escaped, EscapeKey(segment, s.Separator()))
+				}
+				add(strings.Join(escaped, s.Separator()))
+			}
+		default:
+			for _, key := range layer.Keys() {
+				add(key)
+			}
+		}
+	}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	for key := range s.defaults {
+		add(key)
+	}
+	return keys
+}
+
+// locate pinpoints where a layer holds the value for path. Sources other than the built in ones are described by name
+func (s *sourcer) locate(layer Source, path string, options structs.LookupOptions) string {
+	resolved, key := s.resolve(layer, path, options)
+	if p, ok := resolved.(pathSource); ok {
+		return p.locate(key)
+	}
+	return layer.Name()
+}
+
+func (s *sourcer) defaultFor(path string) (string, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	recorded := make([]string, 0, len(s.defaults))
+	for key := range s.defaults {
+		recorded = append(recorded, key)
+	}
+	if key, found := closest(path, recorded); found {
+		return s.defaults[key], true
+	}
+	return "", false
+}
+
+// optionsFor gives the options recorded for path, or none if no struct field gave any
+func (s *sourcer) optionsFor(path string) structs.LookupOptions {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	recorded := make([]string, 0, len(s.lookups))
+	for key := range s.lookups {
+		recorded = append(recorded, key)
+	}
+	if key, found := closest(path, recorded); found {
+		return s.lookups[key]
+	}
+	return structs.LookupOptions{}
+}
+
+// closest picks the recorded key which applies to path - path itself if it was recorded, otherwise the first key in
+// sorted order which matches it regardless of case
+func closest(path string, recorded []string) (string, bool) {
+	matches := make([]string, 0)
+	for _, key := range recorded {
+		if key == path {
+			return key, true
+		}
+		if strings.EqualFold(key, path) {
+			matches = append(matches, key)
+		}
+	}
+	if len(matches) < 1 {
+		return "", false
+	}
+	sort.Strings(matches)
+	return matches[0], true
+}
@@ -0,0 +1,248 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package sourcer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	fileReader "github.com/driscollos/config/internal/sourcer/file-reader"
+)
+
+type fileSource struct {
+	path      string
+	required  bool
+	reader    fileReader.FileReader
+	values    map[string]interface{}
+	locations map[string]interface{}
+	literals  map[string]interface{}
+	included  []string
+}
+
+// parsed holds the values read from a file and the files it includes, where each value was found and whether each
+// value is used exactly as written
+type parsed struct {
+	values    map[string]interface{}
+	locations map[string]interface{}
+	literals  map[string]interface{}
+	included  []string
+}
+
+func (f fileSource) load() ([]Source, error) {
+	bytes, err := f.reader.Read(f.path)
+	if err != nil {
+		if f.required {
+			return nil, fmt.Errorf("could not read from source file : %s", f.path)
+		}
+		return nil, nil
+	}
+
+	file, err := f.parse(f.path, bytes, []string{filepath.Clean(f.path)})
+	if err != nil {
+		return nil, err
+	}
+	f.values = file.values
+	f.locations = file.locations
+	f.literals = file.literals
+	f.included = file.included
+	return []Source{f}, nil
+}
+
+// parse decodes a file and merges in any files it includes. Included files are merged in the order they are listed
+// and the including file overrides them all. The chain holds every file in the current line of includes so that
+// cycles can be detected and reported
+func (f fileSource) parse(path string, bytes []byte, chain []string) (parsed, error) {
+	values, literal, err := loadFromSource(path, bytes)
+	if err != nil {
+		return parsed{}, fmt.Errorf("error parsing source file : %s : %s", path, err.Error())
+	}
+
+	includes, err := f.includes(values)
+	if err != nil {
+		return parsed{}, fmt.Errorf("error parsing source file : %s : %s", path, err.Error())
+	}
+
+	file := parsed{
+		values:    make(map[string]interface{}),
+		locations: make(map[string]interface{}),
+		literals:  make(map[string]interface{}),
+		included:  make([]string, 0),
+	}
+	for _, include := range includes {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(path), include)
+		}
+		include = filepath.Clean(include)
+
+		for _, seen := range chain {
+			if seen == include {
+				return parsed{}, fmt.Errorf(ErrorIncludeCycle, strings.Join(append(chain, include), " -> "))
+			}
+		}
+
+		contents, err := f.reader.Read(include)
+		if err != nil {
+			return parsed{}, fmt.Errorf(ErrorMissingInclude, include, strings.Join(chain, " -> "))
+		}
+
+		nested := make([]string, len(chain), len(chain)+1)
+		copy(nested, chain)
+		includedFile, err := f.parse(include, contents, append(nested, include))
+		if err != nil {
+			return parsed{}, err
+		}
+		merge(file.values, includedFile.values)
+		merge(file.locations, includedFile.locations)
+		merge(file.literals, includedFile.literals)
+		file.included = append(append(file.included, include), includedFile.included...)
+	}
+	merge(file.values, values)
+	merge(file.locations, locationsOf(path, bytes, values))
+	merge(file.literals, mark(values, literal))
+	return file, nil
+}
+
+// mark gives a copy of the structure of data holding flag in place of every value
+func mark(data interface{}, flag bool) map[string]interface{} {
+	marks := make(map[string]interface{})
+	if typed, ok := data.(map[string]interface{}); ok {
+		for key, val := range typed {
+			marks[key] = markValue(val, flag)
+		}
+	}
+	return marks
+}
+
+func markValue(data interface{}, flag bool) interface{} {
+	switch typed := data.(type) {
+	case map[string]interface{}:
+		return mark(typed, flag)
+	case []interface{}:
+		items := make([]interface{}, len(typed))
+		for pos, val := range typed {
+			items[pos] = markValue(val, flag)
+		}
+		return items
+	}
+	return flag
+}
+
+// includes removes the include directive from the top level of a file's values and returns the files it lists. The
+// directive may be named include or $include and may hold a single file or a list of files
+func (f fileSource) includes(values map[string]interface{}) ([]string, error) {
+	includes := make([]string, 0)
+	for _, key := range []string{"include", "$include"} {
+		directive, exists := values[key]
+		if !exists {
+			continue
+		}
+		delete(values, key)
+
+		switch typed := directive.(type) {
+		case string:
+			includes = append(includes, typed)
+		case []interface{}:
+			for _, item := range typed {
+				name, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf(ErrorInvalidInclude, key)
+				}
+				includes = append(includes, name)
+			}
+		default:
+			return nil, fmt.Errorf(ErrorInvalidInclude, key)
+		}
+	}
+	return includes, nil
+}
+
+func (f fileSource) Lookup(key string) (interface{}, bool) {
+	return f.lookupPath(newKeyPath(key, DefaultSeparator, false))
+}
+
+func (f fileSource) lookupPath(path keyPath) (interface{}, bool) {
+	val := walk(f.values, path)
+	return val, val != nil
+}
+
+// literal reports whether the value at path is used exactly as written, because it was read from a file whose format
+// does not allow references to other values
+func (f fileSource) literal(path keyPath) bool {
+	literal, _ := walk(f.literals, path).(bool)
+	return literal
+}
+
+func (f fileSource) locate(path keyPath) string {
+	if location, ok := walk(f.locations, path).(string); ok {
+		return location
+	}
+	return f.path
+}
+
+func (f fileSource) paths() [][]string {
+	return collectPaths(f.values, nil)
+}
+
+func (f fileSource) Keys() []string {
+	return joinPaths(f.paths())
+}
+
+func (f fileSource) Name() string {
+	return f.path
+}
+
+func (f fileSource) watched() []string {
+	return append([]string{f.path}, f.included...)
+}
+
+// collectPaths lists the path to every value held in data, split into its levels of nesting
+func collectPaths(data interface{}, prefix []string) [][]string {
+	paths := make([][]string, 0)
+	switch typed := data.(type) {
+	case map[string]interface{}:
+		for key, val := range typed {
+			paths = append(paths, collectPaths(val, extendPath(prefix, key))...)
+		}
+	case []interface{}:
+		for pos, val := range typed {
+			paths = append(paths, collectPaths(val, extendPath(prefix, strconv.Itoa(pos)))...)
+		}
+	default:
+		if len(prefix) > 0 && data != nil {
+			paths = append(paths, prefix)
+		}
+	}
+	return paths
+}
+
+func extendPath(prefix []string, segment string) []string {
+	path := make([]string, len(prefix), len(prefix)+1)
+	copy(path, prefix)
+	return append(path, segment)
+}
+
+// joinPaths joins the levels of each path with underscores
+func joinPaths(paths [][]string) []string {
+	keys := make([]string, 0, len(paths))
+	for _, path := range paths {
+		keys = append(keys, strings.Join(path, "_"))
+	}
+	return keys
+}
+
+// merge copies the values in src over those in dest, merging nested maps rather than replacing them
+func merge(dest map[string]interface{}, src map[string]interface{}) {
+	for key, val := range src {
+		srcMap, srcIsMap := val.(map[string]interface{})
+		destMap, destIsMap := dest[key].(map[string]interface{})
+		if srcIsMap && destIsMap {
+			merge(destMap, srcMap)
+			continue
+		}
+		dest[key] = val
+	}
+}
@@ -0,0 +1,16 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package fileWatcher
+
+import "time"
+
+const (
+	// DefaultInterval is how often files are checked when the operating system cannot notify us of changes
+	DefaultInterval = 2 * time.Second
+
+	// SettleTime is how long to wait after a change for any others to follow. Editors often write a file in several
+	// steps and a single save should be reported once
+	SettleTime = 100 * time.Millisecond
+)
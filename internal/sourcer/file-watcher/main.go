@@ -0,0 +1,16 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package fileWatcher
+
+import "time"
+
+// New creates a FileWatcher. With an interval of zero changes are reported by the operating system, falling back to
+// checking the files every DefaultInterval where that is not possible. A positive interval always checks the files,
+// which suits file systems such as network mounts that do not report changes
+func New(interval time.Duration) FileWatcher {
+	return watcher{
+		interval: interval,
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package fileWatcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+//go:generate mockgen -destination=../../mocks/mock-file-watcher.go -package=mocks . FileWatcher
+type FileWatcher interface {
+	// Watch calls changed whenever one of the files or directories listed by paths changes, until ctx is done. The
+	// paths need not exist yet. They are listed again after every change so that newly included files are watched too
+	Watch(ctx context.Context, paths func() []string, changed func())
+}
+
+type watcher struct {
+	interval time.Duration
+}
+
+func (w watcher) Watch(ctx context.Context, paths func() []string, changed func()) {
+	if w.interval <= 0 {
+		if events, err := fsnotify.NewWatcher(); err == nil {
+			watching := make(map[string]bool)
+			targets := w.subscribe(events, paths(), watching)
+			go w.notify(ctx, events, targets, watching, paths, changed)
+			return
+		}
+	}
+
+	interval := w.interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	go w.poll(ctx, interval, fingerprint(paths()), paths, changed)
+}
+
+// notify waits for the operating system to report changes. Events are gathered until SettleTime has passed without
+// another, then changed is called once for them all. If the operating system reports an error, such as having dropped
+// events, changed is called in case a change was missed and the paths are polled from then on
+func (w watcher) notify(ctx context.Context, events *fsnotify.Watcher, targets, watching map[string]bool, paths func() []string, changed func()) {
+	defer events.Close()
+
+	settle := time.NewTimer(SettleTime)
+	settle.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			settle.Stop()
+			return
+		case event, ok := <-events.Events:
+			if !ok {
+				return
+			}
+			name := filepath.Clean(event.Name)
+			if watching[name] && event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+				events.Remove(name)
+				delete(watching, name)
+			}
+			if encloses(targets, name) {
+				targets = w.subscribe(events, paths(), watching)
+			}
+			if affects(targets, name) || encloses(targets, name) {
+				settle.Reset(SettleTime)
+			}
+		case _, ok := <-events.Errors:
+			if !ok {
+				return
+			}
+			settle.Stop()
+			if ctx.Err() != nil {
+				return
+			}
+			changed()
+			go w.poll(ctx, DefaultInterval, fingerprint(paths()), paths, changed)
+			return
+		case <-settle.C:
+			if ctx.Err() != nil {
+				return
+			}
+			changed()
+			targets = w.subscribe(events, paths(), watching)
+		}
+	}
+}
+
+// subscribe asks to be told about changes to paths and returns the absolute form of each path. The directory holding
+// each path is watched rather than the path itself, so that files which are created, or replaced by an editor
+// renaming a new copy over them, are still seen. Where that directory does not exist yet, its nearest existing
+// ancestor is watched instead so that its creation is seen and it can be subscribed to in turn. Directories in the list
+// are watched along with everything beneath them
+func (w watcher) subscribe(events *fsnotify.Watcher, paths []string, watching map[string]bool) map[string]bool {
+	add := func(dir string) {
+		if watching[dir] {
+			return
+		}
+		if err := events.Add(dir); err == nil {
+			watching[dir] = true
+		}
+	}
+
+	targets := make(map[string]bool)
+	for _, path := range paths {
+		absolute, err := filepath.Abs(path)
+		if err != nil {
+			continue
+		}
+		targets[absolute] = true
+		add(ancestor(absolute))
+
+		if info, err := os.Stat(absolute); err != nil || !info.IsDir() {
+			continue
+		}
+		filepath.Walk(absolute, func(path string, info os.FileInfo, err error) error {
+			if err == nil && info.IsDir() {
+				add(path)
+			}
+			return nil
+		})
+	}
+	return targets
+}
+
+// ancestor finds the nearest directory above path which exists
+func ancestor(path string) string {
+	dir := filepath.Dir(path)
+	for {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// encloses reports whether name is a directory above one of the targets, whose creation or removal may make a target
+// appear or disappear
+func encloses(targets map[string]bool, name string) bool {
+	for target := range targets {
+		if strings.HasPrefix(target, name+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// affects reports whether a change to name alters one of the targets, either by being a target or lying within one
+func affects(targets map[string]bool, name string) bool {
+	if targets[name] {
+		return true
+	}
+	for target := range targets {
+		if strings.HasPrefix(name, target+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// poll checks the paths every interval, calling changed whenever their fingerprint differs from the last one taken
+func (w watcher) poll(ctx context.Context, interval time.Duration, last string, paths func() []string, changed func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if ctx.Err() != nil {
+				return
+			}
+			if fingerprint(paths()) == last {
+				continue
+			}
+			changed()
+			last = fingerprint(paths())
+		}
+	}
+}
+
+// fingerprint summarises the size and modification time of every path, and of everything beneath the paths which
+// are directories, so that any change to them gives a different fingerprint
+func fingerprint(paths []string) string {
+	var summary strings.Builder
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			summary.WriteString(path + ":missing\n")
+			continue
+		}
+		if !info.IsDir() {
+			summary.WriteString(describe(path, info))
+			continue
+		}
+		filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+			if err == nil {
+				summary.WriteString(describe(path, info))
+			}
+			return nil
+		})
+	}
+	return summary.String()
+}
+
+func describe(path string, info os.FileInfo) string {
+	return path + ":" + info.ModTime().String() + ":" + strconv.FormatInt(info.Size(), 10) + "\n"
+}
@@ -0,0 +1,160 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package fileWatcher
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Unit Tests")
+}
+
+var _ = Describe("File watcher", func() {
+	var (
+		dir     string
+		ctx     context.Context
+		cancel  context.CancelFunc
+		changes chan bool
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "watcher")
+		Expect(err).To(BeNil())
+		ctx, cancel = context.WithCancel(context.Background())
+		changes = make(chan bool, 10)
+	})
+
+	AfterEach(func() {
+		cancel()
+		os.RemoveAll(dir)
+	})
+
+	write := func(name, content string) {
+		Expect(os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)).To(BeNil())
+		Expect(os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)).To(BeNil())
+	}
+
+	watch := func(interval time.Duration, paths ...string) {
+		dir, changes := dir, changes
+		New(interval).Watch(ctx, func() []string {
+			watched := make([]string, 0, len(paths))
+			for _, path := range paths {
+				watched = append(watched, filepath.Join(dir, path))
+			}
+			return watched
+		}, func() {
+			changes <- true
+		})
+	}
+
+	for name, interval := range map[string]time.Duration{"notified": 0, "polling": 10 * time.Millisecond} {
+		interval := interval
+		Context(name, func() {
+			It("should report a file which is changed", func() {
+				write("config.yml", "Port: 8080")
+				watch(interval, "config.yml")
+
+				write("config.yml", "Port: 9090\nHost: localhost")
+				Eventually(changes).Should(Receive())
+			})
+
+			It("should report a file which is created", func() {
+				watch(interval, "config.yml")
+
+				write("config.yml", "Port: 8080")
+				Eventually(changes).Should(Receive())
+			})
+
+			It("should report a file which is removed", func() {
+				write("config.yml", "Port: 8080")
+				watch(interval, "config.yml")
+
+				Expect(os.Remove(filepath.Join(dir, "config.yml"))).To(BeNil())
+				Eventually(changes).Should(Receive())
+			})
+
+			It("should report a change beneath a watched directory", func() {
+				write("secrets/db/password", "secret")
+				watch(interval, "secrets")
+
+				write("secrets/db/password", "changed secret")
+				Eventually(changes).Should(Receive())
+			})
+
+			It("should report a file created in a directory which did not exist", func() {
+				watch(interval, "conf.d/nested/app.yml")
+
+				write("conf.d/nested/app.yml", "Port: 8080")
+				Eventually(changes).Should(Receive())
+				Consistently(changes, 4*SettleTime).ShouldNot(Receive())
+
+				write("conf.d/nested/app.yml", "Port: 9090\nHost: localhost")
+				Eventually(changes).Should(Receive())
+			})
+
+			It("should ignore other files in the same directory", func() {
+				write("config.yml", "Port: 8080")
+				watch(interval, "config.yml")
+
+				write("other.yml", "Port: 9090")
+				Consistently(changes, 4*SettleTime).ShouldNot(Receive())
+			})
+
+			It("should stop watching once the context is done", func() {
+				write("config.yml", "Port: 8080")
+				watch(interval, "config.yml")
+				cancel()
+				time.Sleep(20 * time.Millisecond)
+
+				write("config.yml", "Port: 9090\nHost: localhost")
+				Consistently(changes, 4*SettleTime).ShouldNot(Receive())
+			})
+		})
+	}
+
+	It("should fall back to polling when the operating system reports an error", func() {
+		write("config.yml", "Port: 8080")
+		dir, changes := dir, changes
+		paths := func() []string {
+			return []string{filepath.Join(dir, "config.yml")}
+		}
+		events, err := fsnotify.NewWatcher()
+		Expect(err).To(BeNil())
+		myWatcher := watcher{}
+		watching := make(map[string]bool)
+		go myWatcher.notify(ctx, events, myWatcher.subscribe(events, paths(), watching), watching, paths, func() {
+			changes <- true
+		})
+
+		events.Errors <- errors.New("queue overflow")
+		Eventually(changes).Should(Receive())
+
+		write("config.yml", "Port: 9090\nHost: localhost")
+		Eventually(changes, 2*DefaultInterval).Should(Receive())
+	})
+
+	It("should report a burst of changes once", func() {
+		write("config.yml", "Port: 8080")
+		watch(0, "config.yml")
+
+		for x := 0; x < 5; x++ {
+			write("config.yml", "Port: 909"+string(rune('0'+x)))
+		}
+		Eventually(changes).Should(Receive())
+		Consistently(changes, 4*SettleTime).ShouldNot(Receive())
+	})
+})
@@ -0,0 +1,284 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package sourcer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	dotenvParser "github.com/driscollos/config/internal/sourcer/dotenv-parser"
+	iniParser "github.com/driscollos/config/internal/sourcer/ini-parser"
+	propertiesParser "github.com/driscollos/config/internal/sourcer/properties-parser"
+	"gopkg.in/yaml.v3"
+)
+
+// Decoder turns the contents of a configuration file into nested values
+type Decoder func(source []byte) (map[string]interface{}, error)
+
+// codec describes how to read one kind of file. The values of a literal codec are used exactly as written, so
+// references in them are never expanded - dotenv files apply their own quoting and expansion rules as they are decoded
+type codec struct {
+	decode  Decoder
+	literal bool
+}
+
+var formats = struct {
+	sync.RWMutex
+	decoders map[string]codec
+}{
+	decoders: map[string]codec{
+		"yml":        {decode: decodeYaml},
+		"yaml":       {decode: decodeYaml},
+		"json":       {decode: decodeJson},
+		"toml":       {decode: decodeToml},
+		"env":        {decode: decodeDotenv, literal: true},
+		"properties": {decode: decodeProperties},
+		"ini":        {decode: decodeIni},
+	},
+}
+
+// RegisterFormat makes files ending with extension readable using decoder. Registering an extension which is already
+// known replaces its decoder
+func RegisterFormat(extension string, decoder Decoder) {
+	formats.Lock()
+	defer formats.Unlock()
+	formats.decoders[strings.ToLower(strings.TrimPrefix(extension, "."))] = codec{decode: decoder}
+}
+
+func decoderFor(extension string) (codec, bool) {
+	formats.RLock()
+	defer formats.RUnlock()
+	decoder, exists := formats.decoders[extension]
+	return decoder, exists
+}
+
+// loadFromSource decodes the contents of a file, reporting whether its values are used exactly as written
+func loadFromSource(filename string, source []byte) (map[string]interface{}, bool, error) {
+	extension := fileFormat(filename)
+	if len(extension) < 1 {
+		extension = sniff(source)
+	}
+
+	decoder, exists := decoderFor(extension)
+	if !exists {
+		return nil, false, errors.New(ErrorUnknownFileFormat)
+	}
+
+	data, err := decoder.decode(source)
+	if err != nil {
+		return nil, false, err
+	}
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	return normalise(data).(map[string]interface{}), decoder.literal, nil
+}
+
+// fileFormat determines the format of a file from its name. Dotenv files are recognised by their .env prefix, as in
+// .env.local, and all other files by their extension
+func fileFormat(filename string) string {
+	base := filepath.Base(filename)
+	if base == ".env" || strings.HasPrefix(base, ".env.") {
+		return "env"
+	}
+	return strings.ToLower(strings.TrimPrefix(filepath.Ext(base), "."))
+}
+
+// sniff guesses the format of a file which has no extension by looking at its first meaningful line. It returns an
+// empty string if the content does not look like any known format
+func sniff(source []byte) string {
+	for _, line := range strings.Split(string(source), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) < 1 || line[0] == '#' || line[0] == ';' || line[0] == '!' {
+			continue
+		}
+
+		switch {
+		case line[0] == '{':
+			return "json"
+		case line == "---":
+			return "yaml"
+		case line[0] == '[':
+			if _, err := decodeToml(source); err == nil {
+				return "toml"
+			}
+			return "ini"
+		}
+
+		equals := strings.Index(line, "=")
+		colon := strings.Index(line, ":")
+		switch {
+		case colon > 0 && (equals < 0 || colon < equals):
+			return "yaml"
+		case equals > 0:
+			if _, err := decodeToml(source); err == nil {
+				return "toml"
+			}
+			if hasDottedKeys(source) {
+				return "properties"
+			}
+			return "env"
+		}
+		return ""
+	}
+	return ""
+}
+
+func hasDottedKeys(source []byte) bool {
+	for _, line := range strings.Split(string(source), "\n") {
+		if equals := strings.Index(line, "="); equals > 0 && strings.Contains(line[:equals], ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// normalise converts the typed slices some decoders produce (such as the []map[string]interface{} used for arrays of
+// toml tables) into the []interface{} and map[string]interface{} structure expected when walking a path. Maps keyed
+// by other types, as some yaml decoders produce, are keyed by the text of their keys instead. Numbers which are not
+// finite, such as nan and inf in toml, are kept as text as they cannot be written as json
+func normalise(data interface{}) interface{} {
+	switch typed := data.(type) {
+	case map[string]interface{}:
+		for key, val := range typed {
+			typed[key] = normalise(val)
+		}
+		return typed
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(typed))
+		for key, val := range typed {
+			converted[fmt.Sprintf("%v", key)] = normalise(val)
+		}
+		return converted
+	case float64:
+		if math.IsNaN(typed) || math.IsInf(typed, 0) {
+			return strconv.FormatFloat(typed, 'f', -1, 64)
+		}
+	case float32:
+		if math.IsNaN(float64(typed)) || math.IsInf(float64(typed), 0) {
+			return strconv.FormatFloat(float64(typed), 'f', -1, 32)
+		}
+	case []map[string]interface{}:
+		items := make([]interface{}, len(typed))
+		for pos, val := range typed {
+			items[pos] = normalise(val)
+		}
+		return items
+	case []interface{}:
+		for pos, val := range typed {
+			typed[pos] = normalise(val)
+		}
+		return typed
+	}
+	return data
+}
+
+// nest expands dotted keys such as server.port into the nested maps produced by the other file formats. Maps whose
+// keys are all sequential indexes become slices, so list.0 and list.1 behave like a yaml list. A key which holds a
+// value cannot also have keys nested beneath it, so a=1 alongside a.b=2 is reported as a conflict
+func nest(flat map[string]string) (map[string]interface{}, error) {
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	// sorting places a key before every key nested beneath it, so a conflict is always found while walking the parts
+	sort.Strings(keys)
+
+	data := make(map[string]interface{})
+	for _, key := range keys {
+		parts := strings.Split(key, ".")
+		current := data
+		for pos, part := range parts[:len(parts)-1] {
+			existing, exists := current[part]
+			next, ok := existing.(map[string]interface{})
+			if exists && !ok {
+				return nil, fmt.Errorf(ErrorKeyConflict, strings.Join(parts[:pos+1], "."), key)
+			}
+			if !ok {
+				next = make(map[string]interface{})
+				current[part] = next
+			}
+			current = next
+		}
+		current[parts[len(parts)-1]] = flat[key]
+	}
+
+	for key, val := range data {
+		data[key] = listify(val)
+	}
+	return data, nil
+}
+
+func listify(data interface{}) interface{} {
+	typed, ok := data.(map[string]interface{})
+	if !ok || len(typed) < 1 {
+		return data
+	}
+
+	for key, val := range typed {
+		typed[key] = listify(val)
+	}
+	items := make([]interface{}, len(typed))
+	for key, val := range typed {
+		pos, err := strconv.Atoi(key)
+		if err != nil || pos < 0 || pos >= len(items) || items[pos] != nil {
+			return typed
+		}
+		items[pos] = val
+	}
+	return items
+}
+
+func decodeYaml(source []byte) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	if err := yaml.Unmarshal(source, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func decodeJson(source []byte) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	if err := json.Unmarshal(source, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func decodeToml(source []byte) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	if err := toml.Unmarshal(source, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func decodeDotenv(source []byte) (map[string]interface{}, error) {
+	return dotenvParser.New().Parse(source)
+}
+
+func decodeProperties(source []byte) (map[string]interface{}, error) {
+	parsed, err := propertiesParser.New().Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	return nest(parsed)
+}
+
+func decodeIni(source []byte) (map[string]interface{}, error) {
+	parsed, err := iniParser.New().Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	return nest(parsed)
+}
@@ -0,0 +1,10 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package iniParser
+
+const (
+	ErrorInvalidLine    = "invalid line %d : expected key = value"
+	ErrorInvalidSection = "invalid section header on line %d"
+)
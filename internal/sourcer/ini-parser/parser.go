@@ -0,0 +1,66 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package iniParser
+
+import (
+	"fmt"
+	"strings"
+)
+
+type IniParser interface {
+	Parse(source []byte) (map[string]string, error)
+}
+
+type parser struct{}
+
+// Parse reads an ini file into a map of dotted keys, where each key is prefixed with the section it belongs to -
+// [database] followed by host = localhost becomes database.host
+func (p parser) Parse(source []byte) (map[string]string, error) {
+	values := make(map[string]string)
+	section := ""
+	lines := strings.Split(strings.ReplaceAll(string(source), "\r\n", "\n"), "\n")
+
+	for x, line := range lines {
+		line = strings.TrimSpace(line)
+		if len(line) < 1 || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			if !strings.HasSuffix(line, "]") || len(line) < 3 {
+				return nil, fmt.Errorf(ErrorInvalidSection, x+1)
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		pos := strings.IndexAny(line, "=:")
+		if pos < 1 {
+			return nil, fmt.Errorf(ErrorInvalidLine, x+1)
+		}
+
+		key := strings.TrimSpace(line[:pos])
+		if len(section) > 0 {
+			key = section + "." + key
+		}
+		values[key] = p.value(strings.TrimSpace(line[pos+1:]))
+	}
+	return values, nil
+}
+
+// value removes surrounding quotes or, for unquoted values, any trailing comment
+func (p parser) value(raw string) string {
+	if len(raw) > 1 && (raw[0] == '"' || raw[0] == '\'') {
+		if end := strings.IndexByte(raw[1:], raw[0]); end > -1 {
+			return raw[1 : end+1]
+		}
+	}
+	for _, marker := range []string{" ;", " #"} {
+		if pos := strings.Index(raw, marker); pos > -1 {
+			raw = raw[:pos]
+		}
+	}
+	return strings.TrimSpace(raw)
+}
@@ -0,0 +1,65 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package iniParser
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Unit Tests")
+}
+
+var _ = Describe("Ini parser", func() {
+	var myParser parser
+
+	BeforeEach(func() {
+		myParser = parser{}
+	})
+
+	Context("sample files", func() {
+		When("various forms are used", func() {
+			It("should parse the file correctly", func() {
+				values, err := myParser.Parse([]byte(`
+; global settings
+Name = Billing
+
+[Database]
+Host = localhost ; the primary
+Port: 5432
+Password = "p;ss#word"
+
+[Classes.History]
+Location = Room C4
+`))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(values).To(Equal(map[string]string{
+					"Name":                     "Billing",
+					"Database.Host":            "localhost",
+					"Database.Port":            "5432",
+					"Database.Password":        "p;ss#word",
+					"Classes.History.Location": "Room C4",
+				}))
+			})
+		})
+
+		When("a line is not a key value pair", func() {
+			It("should return an error", func() {
+				_, err := myParser.Parse([]byte("[Database]\nnonsense\n"))
+				Expect(err).To(MatchError("invalid line 2 : expected key = value"))
+			})
+		})
+
+		When("a section header is not closed", func() {
+			It("should return an error", func() {
+				_, err := myParser.Parse([]byte("[Database\nHost = localhost\n"))
+				Expect(err).To(MatchError("invalid section header on line 1"))
+			})
+		})
+	})
+})
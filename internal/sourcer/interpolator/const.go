@@ -0,0 +1,9 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package interpolator
+
+const (
+	ErrorUnterminatedReference = "unterminated reference starting at character %d"
+)
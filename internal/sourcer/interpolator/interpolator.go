@@ -0,0 +1,80 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package interpolator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Interpolator expands references in the form ${NAME} or ${NAME:-default} within a value. The default is used when
+// NAME resolves to a blank value, and may itself contain references. $${ produces a literal ${
+type Interpolator interface {
+	Expand(value string, lookup func(name string) (string, error)) (string, error)
+}
+
+type interpolator struct{}
+
+func (i interpolator) Expand(value string, lookup func(name string) (string, error)) (string, error) {
+	var result strings.Builder
+	for x := 0; x < len(value); x++ {
+		if strings.HasPrefix(value[x:], "$${") {
+			result.WriteString("${")
+			x += 2
+			continue
+		}
+		if !strings.HasPrefix(value[x:], "${") {
+			result.WriteByte(value[x])
+			continue
+		}
+
+		end := i.closingBrace(value, x+2)
+		if end < 0 {
+			return "", fmt.Errorf(ErrorUnterminatedReference, x+1)
+		}
+
+		expanded, err := i.resolve(value[x+2:end], lookup)
+		if err != nil {
+			return "", err
+		}
+		result.WriteString(expanded)
+		x = end
+	}
+	return result.String(), nil
+}
+
+func (i interpolator) resolve(reference string, lookup func(name string) (string, error)) (string, error) {
+	name, fallback, hasFallback := reference, "", false
+	if pos := strings.Index(reference, ":-"); pos > -1 {
+		name, fallback, hasFallback = reference[:pos], reference[pos+2:], true
+	}
+
+	val, err := lookup(strings.TrimSpace(name))
+	if err != nil {
+		return "", err
+	}
+	if len(val) < 1 && hasFallback {
+		return i.Expand(fallback, lookup)
+	}
+	return val, nil
+}
+
+// closingBrace finds the brace which closes a reference starting at pos, allowing for references nested in defaults
+func (i interpolator) closingBrace(value string, pos int) int {
+	depth := 1
+	for x := pos; x < len(value); x++ {
+		switch {
+		case strings.HasPrefix(value[x:], "${"):
+			depth++
+			x++
+		case value[x] == '}':
+			depth--
+			if depth == 0 {
+				return x
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,74 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package interpolator
+
+import (
+	"errors"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Unit Tests")
+}
+
+var _ = Describe("Interpolator", func() {
+	var (
+		myInterpolator interpolator
+		values         map[string]string
+		lookup         func(name string) (string, error)
+	)
+
+	BeforeEach(func() {
+		myInterpolator = interpolator{}
+		values = map[string]string{
+			"DB_USER":       "bob",
+			"Database_Host": "db.internal",
+			"Empty":         "",
+		}
+		lookup = func(name string) (string, error) {
+			return values[name], nil
+		}
+	})
+
+	Context("sample values", func() {
+		When("various forms are used", func() {
+			It("should expand the references correctly", func() {
+				for value, expected := range map[string]string{
+					"no references": "no references",
+					"postgres://${DB_USER}@${Database_Host}:5432": "postgres://bob@db.internal:5432",
+					"${Missing}":             "",
+					"${Missing:-fallback}":   "fallback",
+					"${Empty:-fallback}":     "fallback",
+					"${DB_USER:-fallback}":   "bob",
+					"${Missing:-${DB_USER}}": "bob",
+					"${ DB_USER }":           "bob",
+					"cost: $${DB_USER}":      "cost: ${DB_USER}",
+					"$5 and $DB_USER":        "$5 and $DB_USER",
+				} {
+					Expect(myInterpolator.Expand(value, lookup)).To(Equal(expected), value)
+				}
+			})
+		})
+
+		When("a reference is not closed", func() {
+			It("should return an error which does not repeat the value", func() {
+				_, err := myInterpolator.Expand("ab${cd", lookup)
+				Expect(err).To(MatchError("unterminated reference starting at character 3"))
+			})
+		})
+
+		When("the lookup fails", func() {
+			It("should return the error", func() {
+				_, err := myInterpolator.Expand("${DB_USER}", func(name string) (string, error) {
+					return "", errors.New("lookup failed")
+				})
+				Expect(err).To(MatchError("lookup failed"))
+			})
+		})
+	})
+})
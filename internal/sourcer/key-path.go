@@ -0,0 +1,123 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package sourcer
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// keyPath is a requested key split into its levels of nesting
+type keyPath struct {
+	segments  []string
+	separator string
+	literal   bool
+}
+
+// newKeyPath splits key on separator. A separator preceded by a backslash is part of the name rather than a split, so
+// with the default separator Db_Max\_Connections names max_connections within Db
+func newKeyPath(key, separator string, literal bool) keyPath {
+	if len(separator) < 1 {
+		separator = DefaultSeparator
+	}
+
+	segments := make([]string, 0)
+	var segment strings.Builder
+	for x := 0; x < len(key); x++ {
+		switch {
+		case strings.HasPrefix(key[x:], `\`+separator):
+			segment.WriteString(separator)
+			x += len(separator)
+		case strings.HasPrefix(key[x:], separator):
+			segments = append(segments, segment.String())
+			segment.Reset()
+			x += len(separator) - 1
+		default:
+			segment.WriteByte(key[x])
+		}
+	}
+	return keyPath{
+		segments:  append(segments, segment.String()),
+		separator: separator,
+		literal:   literal,
+	}
+}
+
+// name joins the levels of the path with the separator, without escaping any separators within them. Sources other
+// than the built in ones are given the key in this form
+func (k keyPath) name() string {
+	return strings.Join(k.segments, k.separator)
+}
+
+// variable is the name of the environment variable for the path, where each level of nesting is joined by an
+// underscore and spaces become underscores
+func (k keyPath) variable() string {
+	return strings.Replace(strings.Join(k.segments, "_"), " ", "_", -1)
+}
+
+// EscapeKey protects any separators within a single key, such as a map key, so that it is not split into levels
+func EscapeKey(key, separator string) string {
+	if len(separator) < 1 {
+		separator = DefaultSeparator
+	}
+	return strings.Replace(key, separator, `\`+separator, -1)
+}
+
+// walk finds the value for path within data. Where a key itself contains the separator, as max_connections does with
+// the default separator, the longest run of segments which names a key is preferred. Slices are indexed by number and
+// keys are matched regardless of case unless the path is literal
+func walk(data interface{}, path keyPath) interface{} {
+	if len(path.segments) < 1 {
+		return data
+	}
+
+	switch typed := data.(type) {
+	case map[string]interface{}:
+		for n := len(path.segments); n > 0; n-- {
+			val, exists := child(typed, strings.Join(path.segments[:n], path.separator), path.literal)
+			if !exists {
+				continue
+			}
+			rest := path
+			rest.segments = path.segments[n:]
+			if found := walk(val, rest); found != nil {
+				return found
+			}
+		}
+	case []interface{}:
+		index, err := strconv.Atoi(path.segments[0])
+		if err != nil || index < 0 || index >= len(typed) {
+			return nil
+		}
+		rest := path
+		rest.segments = path.segments[1:]
+		return walk(typed[index], rest)
+	}
+	return nil
+}
+
+// child finds key within data, falling back to a match which ignores case unless literal is set. Where several keys
+// match regardless of case, the first of them in sorted order is used so that the same key is always chosen
+func child(data map[string]interface{}, key string, literal bool) (interface{}, bool) {
+	if val, exists := data[key]; exists {
+		return val, true
+	}
+	if literal {
+		return nil, false
+	}
+
+	matches := make([]string, 0)
+	for name := range data {
+		if strings.EqualFold(name, key) {
+			matches = append(matches, name)
+		}
+	}
+	if len(matches) < 1 {
+		return nil, false
+	}
+	sort.Strings(matches)
+	return data[matches[0]], true
+}
@@ -0,0 +1,84 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package sourcer
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// locationsOf records where each of the values read from a file was found, in a structure which mirrors the values.
+// Values in yaml files are located by line, as in config.yml:12, and values in other formats by the file alone
+func locationsOf(path string, source []byte, values map[string]interface{}) map[string]interface{} {
+	format := fileFormat(path)
+	if len(format) < 1 {
+		format = sniff(source)
+	}
+
+	if format == "yml" || format == "yaml" {
+		var document yaml.Node
+		if err := yaml.Unmarshal(source, &document); err == nil && len(document.Content) > 0 {
+			if lines, ok := nodeLines(path, document.Content[0]).(map[string]interface{}); ok {
+				delete(lines, "include")
+				delete(lines, "$include")
+				return lines
+			}
+		}
+	}
+	return mirror(values, path).(map[string]interface{})
+}
+
+// nodeLines locates every value beneath a yaml node by the line it appears on
+func nodeLines(path string, node *yaml.Node) interface{} {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return nodeLines(path, node.Alias)
+	case yaml.MappingNode:
+		lines := make(map[string]interface{})
+		for x := 0; x+1 < len(node.Content); x += 2 {
+			key, val := node.Content[x], node.Content[x+1]
+			if key.Value != "<<" {
+				lines[key.Value] = nodeLines(path, val)
+				continue
+			}
+
+			// keys merged in from an anchor never replace the keys written alongside them
+			merged, _ := nodeLines(path, val).(map[string]interface{})
+			for name, location := range merged {
+				if _, exists := lines[name]; !exists {
+					lines[name] = location
+				}
+			}
+		}
+		return lines
+	case yaml.SequenceNode:
+		lines := make([]interface{}, 0, len(node.Content))
+		for _, item := range node.Content {
+			lines = append(lines, nodeLines(path, item))
+		}
+		return lines
+	}
+	return fmt.Sprintf("%s:%d", path, node.Line)
+}
+
+// mirror copies the structure of data, with every value replaced by location
+func mirror(data interface{}, location string) interface{} {
+	switch typed := data.(type) {
+	case map[string]interface{}:
+		mirrored := make(map[string]interface{})
+		for key, val := range typed {
+			mirrored[key] = mirror(val, location)
+		}
+		return mirrored
+	case []interface{}:
+		mirrored := make([]interface{}, 0, len(typed))
+		for _, val := range typed {
+			mirrored = append(mirrored, mirror(val, location))
+		}
+		return mirrored
+	}
+	return location
+}
@@ -5,31 +5,119 @@
 package sourcer
 
 import (
+	"fmt"
+	"os"
+	"time"
+
 	fileReader "github.com/driscollos/config/internal/sourcer/file-reader"
+	fileWatcher "github.com/driscollos/config/internal/sourcer/file-watcher"
 	terminalReader "github.com/driscollos/config/internal/sourcer/terminal-reader"
 )
 
-func New() Sourcer {
+// Options customise the sourcer returned by New
+type Options struct {
+	// Sources replaces the default sources with an explicit list, in priority order
+	Sources []Source
+
+	// Profile selects the overlay files, such as config.production.yml, layered over the default files. If it is
+	// blank the profile is read from the environment variable named by ProfileVariable
+	Profile string
+
+	// ProfileVariable names the environment variable holding the active profile. It defaults to APP_ENV
+	ProfileVariable string
+
+	// EnvPrefix is prepended to the names of environment variables, so that with the prefix MYAPP_ the key Port is
+	// read from MYAPP_PORT
+	EnvPrefix string
+
+	// Separator divides the levels of nesting in a key, such as Db_Host. It defaults to an underscore
+	Separator string
+
+	// PollInterval makes Watch check files for changes this often, rather than relying on the operating system to
+	// report them
+	PollInterval time.Duration
+}
+
+func (o Options) profile() string {
+	if len(o.Profile) > 0 {
+		return o.Profile
+	}
+	if len(o.ProfileVariable) > 0 {
+		return os.Getenv(o.ProfileVariable)
+	}
+	return os.Getenv(DefaultProfileVariable)
+}
+
+func New(options Options) Sourcer {
 	s := sourcer{}
 	s.readers.file = fileReader.New()
 	s.readers.terminal = terminalReader.New()
-
-	s.sources.files = []string{
-		"build/config.yml",
-		"build/config.json",
-		"config/config.yml",
-		"config/config.json",
-		"config.yml",
-		"config.json",
-		"env.yml",
-		"env.json",
-		"config.local.yml",
-		"config.local.json",
-		"env.local.yml",
-		"env.local.json",
-	}
+	s.watcher = fileWatcher.New(options.PollInterval)
+	s.sources.chain = options.Sources
+	s.sources.envPrefix = options.EnvPrefix
+	s.separator = options.Separator
+	s.sources.files = defaultFiles(options.profile())
 
 	s.sources.useCommandLine = true
 	s.sources.useEnvironment = true
+
+	// the sources are loaded straight away rather than by whichever goroutine first asks for a value. If they cannot
+	// be loaded now the error is kept until they are reloaded or changed
+	s.setup()
 	return &s
 }
+
+// defaultFiles lists the configuration files examined when none have been given explicitly, from lowest to highest
+// priority. Files for the active profile are layered between the base files and the local files
+func defaultFiles(profile string) []string {
+	groups := []string{"build/config", "config/config", "config", "env"}
+	if len(profile) > 0 {
+		groups = append(groups, fmt.Sprintf("config.%s", profile), fmt.Sprintf("env.%s", profile))
+	}
+	groups = append(groups, "config.local", "env.local")
+
+	files := make([]string, 0)
+	for _, group := range groups {
+		for _, extension := range []string{"yml", "json", "toml"} {
+			files = append(files, fmt.Sprintf("%s.%s", group, extension))
+		}
+	}
+
+	files = append(files, ".env")
+	if len(profile) > 0 {
+		files = append(files, fmt.Sprintf(".env.%s", profile))
+	}
+	return append(files, ".env.local")
+}
+
+func NewTerminalSource() Source {
+	return terminalSource{reader: terminalReader.New()}
+}
+
+func NewEnvironmentSource(prefix string) Source {
+	return environmentSource{prefix: prefix}
+}
+
+func NewFileSource(path string, required bool) Source {
+	return fileSource{
+		path:     path,
+		required: required,
+		reader:   fileReader.New(),
+	}
+}
+
+func NewDirectorySource(path string, required bool) Source {
+	return directorySource{
+		path:     path,
+		required: required,
+		reader:   fileReader.New(),
+	}
+}
+
+func NewDropInSource(path string, required bool) Source {
+	return dropInSource{
+		path:     path,
+		required: required,
+		reader:   fileReader.New(),
+	}
+}
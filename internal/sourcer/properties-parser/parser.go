@@ -0,0 +1,109 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package propertiesParser
+
+import (
+	"strconv"
+	"strings"
+)
+
+type PropertiesParser interface {
+	Parse(source []byte) (map[string]string, error)
+}
+
+type parser struct{}
+
+func (p parser) Parse(source []byte) (map[string]string, error) {
+	values := make(map[string]string)
+	lines := strings.Split(strings.ReplaceAll(string(source), "\r\n", "\n"), "\n")
+
+	for x := 0; x < len(lines); x++ {
+		line := strings.TrimLeft(lines[x], " \t\f")
+		if len(line) < 1 || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+
+		for p.continues(line) {
+			line = line[:len(line)-1]
+			if x+1 >= len(lines) {
+				break
+			}
+			x++
+			line += strings.TrimLeft(lines[x], " \t\f")
+		}
+
+		key, value := p.split(line)
+		values[p.unescape(key)] = p.unescape(value)
+	}
+	return values, nil
+}
+
+// continues reports whether a line ends with an unescaped backslash, meaning the value carries on to the next line
+func (p parser) continues(line string) bool {
+	count := 0
+	for x := len(line) - 1; x >= 0 && line[x] == '\\'; x-- {
+		count++
+	}
+	return count%2 == 1
+}
+
+// split separates a key from its value. The key ends at the first unescaped '=', ':' or whitespace
+func (p parser) split(line string) (string, string) {
+	end := len(line)
+	for x := 0; x < len(line); x++ {
+		if line[x] == '\\' {
+			x++
+			continue
+		}
+		if line[x] == '=' || line[x] == ':' || line[x] == ' ' || line[x] == '\t' || line[x] == '\f' {
+			end = x
+			break
+		}
+	}
+
+	key := line[:end]
+	value := strings.TrimLeft(line[end:], " \t\f")
+	if len(value) > 0 && (value[0] == '=' || value[0] == ':') {
+		value = strings.TrimLeft(value[1:], " \t\f")
+	}
+	return key, strings.TrimRight(value, " \t\f")
+}
+
+func (p parser) unescape(val string) string {
+	if !strings.Contains(val, "\\") {
+		return val
+	}
+
+	var result strings.Builder
+	for x := 0; x < len(val); x++ {
+		if val[x] != '\\' || x+1 >= len(val) {
+			result.WriteByte(val[x])
+			continue
+		}
+		x++
+		switch val[x] {
+		case 't':
+			result.WriteByte('\t')
+		case 'n':
+			result.WriteByte('\n')
+		case 'r':
+			result.WriteByte('\r')
+		case 'f':
+			result.WriteByte('\f')
+		case 'u':
+			if x+4 < len(val) {
+				if code, err := strconv.ParseUint(val[x+1:x+5], 16, 32); err == nil {
+					result.WriteRune(rune(code))
+					x += 4
+					continue
+				}
+			}
+			result.WriteByte('u')
+		default:
+			result.WriteByte(val[x])
+		}
+	}
+	return result.String()
+}
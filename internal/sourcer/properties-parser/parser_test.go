@@ -0,0 +1,48 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package propertiesParser
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Unit Tests")
+}
+
+var _ = Describe("Properties parser", func() {
+	Context("sample files", func() {
+		When("various forms are used", func() {
+			It("should parse the file correctly", func() {
+				myParser := parser{}
+				values, err := myParser.Parse([]byte(`
+# comment
+! also a comment
+server.port=8080
+server.host : localhost
+app.name Billing Service
+app.description = A long \
+    description
+path.windows = C:\\temp
+greeting = caf\u00e9
+key\=with\=equals = yes
+`))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(values).To(Equal(map[string]string{
+					"server.port":     "8080",
+					"server.host":     "localhost",
+					"app.name":        "Billing Service",
+					"app.description": "A long description",
+					"path.windows":    `C:\temp`,
+					"greeting":        "café",
+					"key=with=equals": "yes",
+				}))
+			})
+		})
+	})
+})
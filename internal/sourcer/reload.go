@@ -0,0 +1,256 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package sourcer
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/driscollos/config/internal/structs"
+)
+
+// OnChange registers a callback which is told the keys whose values changed each time the sources are reloaded
+func (s *sourcer) OnChange(callback func(changedKeys []string)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.callbacks = append(s.callbacks, callback)
+}
+
+// OnError registers a callback which is told why a reload failed or why the references in a value could not be
+// resolved. References are resolved each time the sources are loaded, and each value which could not be resolved is
+// reported once for every load. The callback is told straight away about those in the sources already loaded
+func (s *sourcer) OnError(callback func(err error)) {
+	s.mutex.Lock()
+	s.failures = append(s.failures, callback)
+	s.mutex.Unlock()
+
+	if current := s.loaded(); current != nil {
+		for _, problem := range current.problems {
+			callback(problem)
+		}
+	}
+}
+
+// Validate registers a validator which every reload must pass before its layers are used
+func (s *sourcer) Validate(validator structs.Validator) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.validators = append(s.validators, validator)
+}
+
+// Reload reads every source again and swaps the new layers in whole, so that a lookup never sees a mixture of old and
+// new values. The new layers are only used once every validator accepts them. If a source cannot be read or a
+// validator rejects the layers, the current layers are kept and the error is returned and given to the callbacks
+// registered with OnError. Callbacks registered with OnChange are called once the new layers are in place, if any
+// values changed
+func (s *sourcer) Reload() error {
+	s.reloading.Lock()
+	defer s.reloading.Unlock()
+	return s.refresh(s.view(s.latest()).snapshot(), false)
+}
+
+// refresh loads the sources and puts the new state in place once every validator accepts it, telling the callbacks
+// registered with OnChange which values changed since before was taken. If a validator rejects the new state the
+// current one is kept. If the sources cannot be read the current state is kept too, unless discard asks for it to be
+// dropped because the sources have changed since it was loaded, or it holds an earlier failure to load them - the
+// state then holds the new error instead. Errors are returned and given to the callbacks registered with OnError.
+// The caller must hold reloading
+func (s *sourcer) refresh(before map[string]string, discard bool) error {
+	s.mutex.RLock()
+	chain, generation := s.chain(), s.generation
+	validators := append(make([]structs.Validator, 0, len(s.validators)), s.validators...)
+	s.mutex.RUnlock()
+
+	written, err := s.build(chain)
+	if err != nil {
+		current := s.loaded()
+		if (discard || current == nil || current.err != nil) && s.store(generation, &state{err: err}) {
+			s.notify(changedKeys(before, map[string]string{}))
+		}
+		return s.fail(err)
+	}
+
+	next := s.resolved(written)
+	candidate := s.view(next)
+	commits := make([]func(), 0, len(validators))
+	for _, validator := range validators {
+		commit, err := validator(candidate)
+		if err != nil {
+			return s.fail(err)
+		}
+		commits = append(commits, commit)
+	}
+
+	// sources which were changed while reloading are loaded afresh when they are next needed
+	if !s.store(generation, next) {
+		return nil
+	}
+	for _, commit := range commits {
+		if commit != nil {
+			commit()
+		}
+	}
+	s.report(next.problems)
+	s.notify(changedKeys(before, candidate.snapshot()))
+	return nil
+}
+
+// notify tells the callbacks registered with OnChange which keys changed, if any did
+func (s *sourcer) notify(changed []string) {
+	if len(changed) < 1 {
+		return
+	}
+
+	s.mutex.RLock()
+	callbacks := append(make([]func(changedKeys []string), 0, len(s.callbacks)), s.callbacks...)
+	s.mutex.RUnlock()
+
+	for _, callback := range callbacks {
+		callback(changed)
+	}
+}
+
+// fail gives err to the callbacks registered with OnError and returns it
+func (s *sourcer) fail(err error) error {
+	s.mutex.RLock()
+	failures := append(make([]func(err error), 0, len(s.failures)), s.failures...)
+	s.mutex.RUnlock()
+
+	for _, callback := range failures {
+		callback(err)
+	}
+	return err
+}
+
+// report gives each problem found while loading the sources to the callbacks registered with OnError
+func (s *sourcer) report(problems []error) {
+	for _, problem := range problems {
+		s.fail(problem)
+	}
+}
+
+// view creates a sourcer which answers from the state given, whether or not it is in use, so that validators can try
+// out reloaded layers and reloads can compare the values before and after. It shares the separator, defaults, lookup
+// options and secrets of s but none of its callbacks
+func (s *sourcer) view(current *state) *sourcer {
+	view := &sourcer{
+		separator: s.separator,
+		defaults:  make(map[string]string),
+		lookups:   make(map[string]structs.LookupOptions),
+		secrets:   make(map[string]bool),
+	}
+	view.state.Store(current)
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	view.readers = s.readers
+	view.sources = s.sources
+	for key, val := range s.defaults {
+		view.defaults[key] = val
+	}
+	for key, val := range s.lookups {
+		view.lookups[key] = val
+	}
+	for key, val := range s.secrets {
+		view.secrets[key] = val
+	}
+	return view
+}
+
+// Watch reloads the sources whenever one of the files or directories they read changes, until ctx is done. Optional
+// files which do not exist yet are watched too, so that creating one is noticed. A change which leaves a file
+// unreadable, or which a validator rejects, keeps the values read before it and is reported to the callbacks
+// registered with OnError. Only one watch may run at a time - another can be started once the context of the first
+// is done
+func (s *sourcer) Watch(ctx context.Context) error {
+	if _, err := s.current(); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	if s.watching {
+		s.mutex.Unlock()
+		return errors.New(ErrorAlreadyWatching)
+	}
+	s.watching = true
+	s.mutex.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		s.watching = false
+	}()
+
+	s.watcher.Watch(ctx, s.watched, func() {
+		s.Reload()
+	})
+	return nil
+}
+
+// watched lists every file and directory read by the sources, or which would be read if it existed. Files included
+// by other files are only known once the sources have been loaded
+func (s *sourcer) watched() []string {
+	s.mutex.RLock()
+	sources := append(make([]Source, 0), s.chain()...)
+	s.mutex.RUnlock()
+	if current := s.loaded(); current != nil {
+		sources = append(sources, current.layers...)
+	}
+
+	paths := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, source := range sources {
+		w, ok := source.(watchable)
+		if !ok {
+			continue
+		}
+		for _, path := range w.watched() {
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths
+}
+
+// snapshot records the value of every key, so that a reload can tell which of them changed
+func (s *sourcer) snapshot() map[string]string {
+	values := make(map[string]string)
+	current := s.latest()
+	for _, key := range s.keys(current.layers) {
+		val, _ := s.value(current, key)
+		values[key] = format(val)
+	}
+	return values
+}
+
+// changedKeys lists the keys which were added, removed or given a different value between two snapshots. Keys which
+// differ only in case are the same key
+func changedKeys(before, after map[string]string) []string {
+	previous := make(map[string]string)
+	for key, val := range before {
+		previous[strings.ToLower(key)] = val
+	}
+
+	changed := make([]string, 0)
+	current := make(map[string]bool)
+	for key, val := range after {
+		current[strings.ToLower(key)] = true
+		if old, existed := previous[strings.ToLower(key)]; !existed || old != val {
+			changed = append(changed, key)
+		}
+	}
+	for key := range before {
+		if !current[strings.ToLower(key)] {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
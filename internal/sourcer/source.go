@@ -0,0 +1,43 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package sourcer
+
+// Source is a single provider of configuration values. Sources are consulted in the order they are given to the
+// sourcer and the first source which knows a key provides its value
+type Source interface {
+	// Lookup returns the value stored against key and whether the source knows the key at all
+	Lookup(key string) (interface{}, bool)
+
+	// Keys lists every key the source is able to answer
+	Keys() []string
+
+	// Name describes the source for diagnostic purposes
+	Name() string
+}
+
+// loader is implemented by sources which need to read data before they can answer lookups. A loader may expand into
+// any number of loaded sources - an optional file which does not exist loads as none at all
+type loader interface {
+	load() ([]Source, error)
+}
+
+// pathSource is implemented by the built in sources, which understand keys split into levels of nesting. Other
+// sources are given the key as it was requested, with any escaped separators unescaped so that Db_max\_conns is
+// looked up as Db_max_conns
+type pathSource interface {
+	lookupPath(path keyPath) (interface{}, bool)
+
+	// locate pinpoints where the source holds the value for path, such as the file and line
+	locate(path keyPath) string
+
+	// paths lists every key the source holds, split into its levels of nesting
+	paths() [][]string
+}
+
+// watchable is implemented by sources read from the file system. watched lists every file or directory whose changes
+// could alter the values the source holds
+type watchable interface {
+	watched() []string
+}
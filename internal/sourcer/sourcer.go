@@ -5,23 +5,43 @@
 package sourcer
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"os"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	fileReader "github.com/driscollos/config/internal/sourcer/file-reader"
+	fileWatcher "github.com/driscollos/config/internal/sourcer/file-watcher"
+	"github.com/driscollos/config/internal/sourcer/interpolator"
 	terminalReader "github.com/driscollos/config/internal/sourcer/terminal-reader"
-	"gopkg.in/yaml.v3"
+	"github.com/driscollos/config/internal/structs"
 )
 
 //go:generate mockgen -destination=../mocks/mock-data-sourcer.go -package=mocks . Sourcer
 type Sourcer interface {
-	Get(path string) string
+	Default(path, value string)
+	Err() error
+	Explain(path string) structs.Explanation
+	Get(path string, options ...structs.LookupOptions) string
+	LookupOptions(path string, options structs.LookupOptions)
+	OnChange(callback func(changedKeys []string))
+	OnError(callback func(err error))
+	Origin(path string) string
+	Provenance() map[string]structs.Explanation
+	Redact(path string)
+	Reload() error
+	Separator() string
 	Source(path string)
+	SourceFiles(files []structs.SourceFile)
+	UseOverrides(enabled bool)
+	Validate(validator structs.Validator)
+	Values() map[string]interface{}
+	Watch(ctx context.Context) error
 }
 
 type sourcer struct {
@@ -29,160 +49,400 @@ type sourcer struct {
 		file     fileReader.FileReader
 		terminal terminalReader.TerminalReader
 	}
+	watcher fileWatcher.FileWatcher
 	sources struct {
+		chain          []Source
+		envPrefix      string
 		files          []string
+		required       map[string]bool
 		useCommandLine bool
 		useEnvironment bool
 	}
-	isSetup bool
-	values  []map[string]interface{}
+	callbacks  []func(changedKeys []string)
+	defaults   map[string]string
+	failures   []func(err error)
+	generation int
+	lookups    map[string]structs.LookupOptions
+	secrets    map[string]bool
+	separator  string
+	state      atomic.Value
+	validators []structs.Validator
+	watching   bool
+
+	// mutex guards everything which can be changed after the sourcer is created, other than the state. loading makes
+	// sure the sources are only loaded by one goroutine at a time and reloading that only one reload runs at a time
+	mutex     sync.RWMutex
+	loading   sync.Mutex
+	reloading sync.Mutex
+}
+
+// state holds the layers loaded from the sources at one moment. A state is never changed once it has been stored -
+// loading the sources again stores a new state in its place, so a lookup always sees a single consistent set of layers
+// without needing a lock
+type state struct {
+	// written holds the layers as they were read, and layers the same layers with the references in the values of
+	// configuration files resolved. problems explains each value whose references could not be resolved, and err why
+	// the sources could not be loaded at all - in which case there are no layers
+	layers   []Source
+	written  []Source
+	problems []error
+	err      error
+}
+
+// loaded returns the current state, or nil if the sources have not been loaded since they were last changed
+func (s *sourcer) loaded() *state {
+	current, _ := s.state.Load().(*state)
+	return current
+}
+
+// current returns the state to consult, loading the sources if they have not been loaded since they were last
+// changed. If the sources cannot be loaded the error is kept, so that lookups do not read the sources again and again -
+// they are only tried again by a reload or once the sources are changed
+func (s *sourcer) current() (*state, error) {
+	if current := s.loaded(); current != nil {
+		return current, current.err
+	}
+
+	s.loading.Lock()
+	if current := s.loaded(); current != nil {
+		s.loading.Unlock()
+		return current, current.err
+	}
+
+	s.mutex.RLock()
+	chain, generation := s.chain(), s.generation
+	s.mutex.RUnlock()
+
+	written, err := s.build(chain)
+	if err != nil {
+		s.store(generation, &state{err: err})
+		s.loading.Unlock()
+		return nil, err
+	}
+	next := s.resolved(written)
+	stored := s.store(generation, next)
+	s.loading.Unlock()
+
+	// problems are reported once the lock is released, so that the callbacks may look values up themselves
+	if stored {
+		s.report(next.problems)
+	}
+	return next, nil
 }
 
+// setup loads the sources if they have not been loaded already
 func (s *sourcer) setup() error {
-	if s.isSetup {
-		return nil
+	_, err := s.current()
+	return err
+}
+
+// Err reports why the sources could not be loaded, or nil if they were
+func (s *sourcer) Err() error {
+	return s.setup()
+}
+
+// latest returns the state to consult, or an empty state if the sources cannot be loaded. Each lookup takes the state
+// once and passes its layers down, so that every value it reads comes from the same state
+func (s *sourcer) latest() *state {
+	current, err := s.current()
+	if err != nil {
+		return &state{}
 	}
+	return current
+}
 
-	s.values = make([]map[string]interface{}, 0)
-	for _, file := range s.sources.files {
-		bytes, err := s.readers.file.Read(file)
-		if err != nil {
-			if len(s.sources.files) == 1 {
-				return fmt.Errorf("could not read from source file : %s", file)
-			}
-			continue
-		}
-		if err = s.loadFromSource(file, bytes); err != nil {
-			return fmt.Errorf("error parsing source file : %s : %s", file, err.Error())
-		}
+// store makes next the current state, as long as it was loaded from the sources as they are now. A state loaded
+// before the sources were changed is discarded and false is returned
+func (s *sourcer) store(generation int, next *state) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if generation != s.generation {
+		return false
 	}
-	s.isSetup = true
-	return nil
+	s.state.Store(next)
+	return true
 }
 
-func (s *sourcer) loadFromSource(filename string, source []byte) error {
-	data := make(map[string]interface{})
-	bits := strings.Split(filename, ".")
-	if len(bits) < 2 {
-		return errors.New(ErrorUnknownFileFormat)
+// reconfigure changes the sources. If nothing has been read from the sources yet they are loaded when a value is next
+// needed. Otherwise they are loaded straight away and put in place in the same way as by a reload - once every
+// validator accepts them, telling the callbacks registered with OnChange which values changed
+func (s *sourcer) reconfigure(change func()) {
+	s.reloading.Lock()
+	defer s.reloading.Unlock()
+
+	s.mutex.Lock()
+	previous := s.loaded()
+	change()
+	s.generation++
+	s.mutex.Unlock()
+
+	if previous != nil {
+		s.refresh(s.view(previous).snapshot(), true)
 	}
+}
 
-	switch bits[len(bits)-1] {
-	case "yml", "yaml":
-		if err := yaml.Unmarshal(source, &data); err != nil {
-			return err
+// chain lists the sources to consult, in priority order
+func (s *sourcer) chain() []Source {
+	if s.sources.chain != nil {
+		return s.sources.chain
+	}
+	return s.defaultChain()
+}
+
+// build loads every source in the chain, giving the layers which answer lookups
+func (s *sourcer) build(chain []Source) ([]Source, error) {
+	layers := make([]Source, 0)
+	for _, source := range chain {
+		l, ok := source.(loader)
+		if !ok {
+			layers = append(layers, source)
+			continue
 		}
-	case "json":
-		if err := json.Unmarshal(source, &data); err != nil {
-			return err
+		loaded, err := l.load()
+		if err != nil {
+			return nil, err
 		}
-	default:
-		return errors.New(ErrorUnknownFileFormat)
+		layers = append(layers, loaded...)
 	}
+	return layers, nil
+}
 
-	s.values = append(s.values, data)
-	return nil
+// defaultChain builds the sources used when none have been given explicitly. Terminal arguments take priority over
+// environment variables, which take priority over files - later files in the list override earlier ones
+func (s *sourcer) defaultChain() []Source {
+	chain := make([]Source, 0)
+	if s.sources.useCommandLine {
+		chain = append(chain, terminalSource{reader: s.readers.terminal})
+	}
+	if s.sources.useEnvironment {
+		chain = append(chain, environmentSource{prefix: s.sources.envPrefix})
+	}
+	for x := len(s.sources.files) - 1; x >= 0; x-- {
+		chain = append(chain, fileSource{
+			path:     s.sources.files[x],
+			required: s.sources.required[s.sources.files[x]],
+			reader:   s.readers.file,
+		})
+	}
+	return chain
 }
 
+// Source makes the file at path the only source, switching off terminal arguments and environment variables
 func (s *sourcer) Source(path string) {
-	s.sources.useCommandLine = false
-	s.sources.useEnvironment = false
-	s.sources.files = []string{path}
-	s.isSetup = false
+	s.reconfigure(func() {
+		s.useFiles([]structs.SourceFile{{Path: path, Required: true}})
+		s.sources.useCommandLine = false
+		s.sources.useEnvironment = false
+	})
 }
 
-func (s *sourcer) Get(path string) string {
-	if err := s.setup(); err != nil {
-		return ""
+// SourceFiles replaces the configuration files with those given. The first file has the highest priority
+func (s *sourcer) SourceFiles(files []structs.SourceFile) {
+	s.reconfigure(func() {
+		s.useFiles(files)
+	})
+}
+
+// useFiles replaces the configuration files, and any sources given explicitly, with the files given
+func (s *sourcer) useFiles(files []structs.SourceFile) {
+	s.sources.chain = nil
+	s.sources.files = make([]string, 0, len(files))
+	s.sources.required = make(map[string]bool)
+	for x := len(files) - 1; x >= 0; x-- {
+		s.sources.files = append(s.sources.files, files[x].Path)
+		s.sources.required[files[x].Path] = files[x].Required
 	}
+}
 
-	var retVal interface{}
+// UseOverrides controls whether terminal arguments and environment variables take priority over configuration files
+func (s *sourcer) UseOverrides(enabled bool) {
+	s.reconfigure(func() {
+		s.sources.useCommandLine = enabled
+		s.sources.useEnvironment = enabled
+	})
+}
 
-	if s.sources.useCommandLine {
-		argVal, err := s.readers.terminal.Get(path)
-		if err == nil {
-			return argVal
-		}
+// Get returns the value for path as a string. Keys are matched regardless of case unless the options ask for a
+// literal match
+func (s *sourcer) Get(path string, options ...structs.LookupOptions) string {
+	var lookup structs.LookupOptions
+	if len(options) > 0 {
+		lookup = options[0]
 	}
 
-	if s.sources.useEnvironment {
-		if len(os.Getenv(strings.Replace(path, " ", "_", -1))) > 0 {
-			return os.Getenv(strings.Replace(path, " ", "_", -1))
+	val, _ := s.find(s.latest().layers, path, lookup)
+	return format(val)
+}
+
+// resolved resolves the references in the values of configuration files, giving the state to store for the layers
+// as they were written. Each text value is resolved on its own, so nested values hold the resolved text too. A value
+// whose references cannot be resolved is kept as it was written and the reason recorded among the problems
+func (s *sourcer) resolved(written []Source) *state {
+	next := &state{layers: make([]Source, 0, len(written)), written: written, problems: make([]error, 0)}
+	for _, layer := range written {
+		f, ok := layer.(fileSource)
+		if ok {
+			f.values = s.resolveValues(written, f, f.values, nil, &next.problems).(map[string]interface{})
+			layer = f
 		}
+		next.layers = append(next.layers, layer)
 	}
+	return next
+}
 
-	for _, source := range s.values {
-		val := s.get(source, path)
-		if val != nil {
-			retVal = val
+// resolveValues walks the values read from f, resolving the references in each text value found below path
+func (s *sourcer) resolveValues(written []Source, f fileSource, data interface{}, path []string, problems *[]error) interface{} {
+	switch typed := data.(type) {
+	case map[string]interface{}:
+		resolved := make(map[string]interface{}, len(typed))
+		for key, val := range typed {
+			resolved[key] = s.resolveValues(written, f, val, extendPath(path, key), problems)
 		}
+		return resolved
+	case []interface{}:
+		resolved := make([]interface{}, len(typed))
+		for pos, val := range typed {
+			resolved[pos] = s.resolveValues(written, f, val, extendPath(path, strconv.Itoa(pos)), problems)
+		}
+		return resolved
+	case string:
+		if !strings.Contains(typed, "${") || f.literal(keyPath{segments: path, separator: s.Separator()}) {
+			return typed
+		}
+		key := s.joinPath(path)
+		resolved, err := s.interpolate(written, typed, []string{key})
+		if err != nil {
+			*problems = append(*problems, fmt.Errorf(ErrorInterpolation, key, err.Error()))
+			return typed
+		}
+		return resolved
 	}
+	return data
+}
 
-	if retVal == nil {
-		return ""
+// joinPath gives the key for a path split into its levels of nesting, escaping separators within each level
+func (s *sourcer) joinPath(path []string) string {
+	escaped := make([]string, 0, len(path))
+	for _, segment := range path {
+		escaped = append(escaped, EscapeKey(segment, s.Separator()))
 	}
+	return strings.Join(escaped, s.Separator())
+}
 
-	switch reflect.TypeOf(retVal).Kind() {
-	case reflect.Slice, reflect.Map:
-		bytes, _ := json.Marshal(retVal)
-		return string(bytes)[1 : len(string(bytes))-1]
-	case reflect.Float32, reflect.Float64:
-		return strings.TrimSpace(fmt.Sprintf("%f", retVal))
+// expands reports whether the value layer holds for path may refer to other values. Only values from configuration
+// files are expanded - dotenv files, directories, environment variables, terminal arguments and other sources are
+// used exactly as given
+func (s *sourcer) expands(layer Source, path string, options structs.LookupOptions) bool {
+	f, ok := layer.(fileSource)
+	if !ok {
+		return false
 	}
-	return strings.TrimSpace(fmt.Sprintf("%v", retVal))
+	_, key := s.resolve(layer, path, options)
+	return !f.literal(key)
 }
 
-func (s *sourcer) get(source map[string]interface{}, path string) interface{} {
-	if err := s.setup(); err != nil {
-		return nil
+// interpolate expands ${NAME} references in a value using other configuration keys, as they were written, falling
+// back to environment variables. The keys currently being resolved are tracked so that references which loop back on
+// themselves are reported rather than followed forever
+func (s *sourcer) interpolate(written []Source, value string, resolving []string) (string, error) {
+	if !strings.Contains(value, "${") {
+		return value, nil
 	}
 
-	bits := strings.Split(path, "_")
-	if len(bits) == 1 {
-		data := source[path]
-		return data
+	return interpolator.New().Expand(value, func(name string) (string, error) {
+		chain := make([]string, len(resolving), len(resolving)+1)
+		copy(chain, resolving)
+		chain = append(chain, name)
+		for _, key := range resolving {
+			if key == name {
+				return "", fmt.Errorf(ErrorInterpolationCycle, strings.Join(chain, " -> "))
+			}
+		}
+
+		val, layer := s.find(written, name, structs.LookupOptions{})
+		if layer == nil {
+			return os.Getenv(name), nil
+		}
+		if !s.expands(layer, name, structs.LookupOptions{}) {
+			return format(val), nil
+		}
+		return s.interpolate(written, format(val), chain)
+	})
+}
+
+// Origin names the source which supplies the value for path - a file path, "environment" or "command line"
+func (s *sourcer) Origin(path string) string {
+	_, layer := s.find(s.latest().layers, path, structs.LookupOptions{})
+	if layer == nil {
+		return ""
 	}
+	return layer.Name()
+}
 
-	extract, exists := source[bits[0]]
-	if !exists {
-		return nil
+// Separator is the text which divides the levels of nesting in a key
+func (s *sourcer) Separator() string {
+	if len(s.separator) < 1 {
+		return DefaultSeparator
 	}
+	return s.separator
+}
 
-	bits = bits[1:]
-	for pos, part := range bits {
-		if extract == nil {
-			continue
+// find looks path up in each of the layers in turn, returning the first value found along with the layer holding it
+func (s *sourcer) find(layers []Source, path string, options structs.LookupOptions) (interface{}, Source) {
+	for _, layer := range layers {
+		val, found := s.lookup(layer, path, options)
+		if found {
+			return val, layer
 		}
+	}
+	return nil, nil
+}
 
-		if pos == len(bits)-1 {
-			if reflect.TypeOf(extract).Kind() == reflect.Slice {
-				partAsInt, err := strconv.Atoi(part)
-				if err != nil {
-					return nil
-				}
-				if len(part) < (partAsInt + 1) {
-					return nil
-				}
-				return extract.([]interface{})[partAsInt]
-			}
-			if extract == nil || reflect.TypeOf(extract).Kind() != reflect.Map {
-				return nil
-			}
-			return extract.(map[string]interface{})[part]
-		}
+// lookup asks a single layer for the value at path
+func (s *sourcer) lookup(layer Source, path string, options structs.LookupOptions) (interface{}, bool) {
+	resolved, key := s.resolve(layer, path, options)
+	if p, ok := resolved.(pathSource); ok {
+		return p.lookupPath(key)
+	}
+	return layer.Lookup(key.name())
+}
 
-		partAsInt, err := strconv.Atoi(part)
-		if err == nil && reflect.TypeOf(extract).Kind() == reflect.Slice {
-			if len(extract.([]interface{})) > partAsInt {
-				extract = extract.([]interface{})[partAsInt]
-				continue
-			}
+// resolve works out how a layer should be asked for path, using the name given for that kind of source if there is
+// one. Environment variables named explicitly are read without any prefix
+func (s *sourcer) resolve(layer Source, path string, options structs.LookupOptions) (Source, keyPath) {
+	switch typed := layer.(type) {
+	case environmentSource:
+		if len(options.Env) > 0 {
+			typed.prefix = ""
+			return typed, keyPath{segments: []string{options.Env}, literal: options.Literal}
+		}
+	case terminalSource:
+		if len(options.Flag) > 0 {
+			return typed, keyPath{segments: []string{options.Flag}, literal: options.Literal}
+		}
+	case fileSource, directorySource:
+		if len(options.File) > 0 {
+			path = options.File
 		}
+	}
+	return layer, newKeyPath(path, s.separator, options.Literal)
+}
 
-		if reflect.TypeOf(extract).Kind() != reflect.Map {
-			return nil
+func format(val interface{}) string {
+	if val == nil {
+		return ""
+	}
+
+	switch reflect.TypeOf(val).Kind() {
+	case reflect.Slice, reflect.Map:
+		// values json cannot represent are given as go would print them rather than lost
+		bytes, err := json.Marshal(val)
+		if err != nil || len(bytes) < 2 {
+			return strings.TrimSpace(fmt.Sprintf("%v", val))
 		}
-		extract = extract.(map[string]interface{})[part]
+		return string(bytes[1 : len(bytes)-1])
+	case reflect.Float32, reflect.Float64:
+		return strings.TrimSpace(fmt.Sprintf("%f", val))
 	}
-	return nil
+	return strings.TrimSpace(fmt.Sprintf("%v", val))
 }
@@ -5,13 +5,18 @@
 package sourcer
 
 import (
+	"context"
 	"errors"
 	"github.com/driscollos/config/internal/mocks"
+	fileReader "github.com/driscollos/config/internal/sourcer/file-reader"
+	"github.com/driscollos/config/internal/structs"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -20,6 +25,25 @@ func TestSuite(t *testing.T) {
 	RunSpecs(t, "Unit Tests")
 }
 
+type mapSource map[string]string
+
+func (m mapSource) Lookup(key string) (interface{}, bool) {
+	val, exists := m[key]
+	return val, exists
+}
+
+func (m mapSource) Keys() []string {
+	keys := make([]string, 0)
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (m mapSource) Name() string {
+	return "map"
+}
+
 var _ = Describe("Sourcer Unit Tests", func() {
 	var (
 		mockController     *gomock.Controller
@@ -78,6 +102,189 @@ Age: 41
 			})
 		})
 
+		When("a toml file is processed", func() {
+			It("should understand the contents of the toml file correctly", func() {
+				mockTerminalReader.EXPECT().Get(gomock.Any()).Return("", errors.New("not_found")).Times(5)
+				mockFileReader.EXPECT().Read("test.toml").Return([]byte(strings.TrimSpace(`
+Name = "Bob"
+Age = 41
+
+[Hobbies.Sports]
+First = "Skating"
+
+[[Pets]]
+Name = "Rex"
+
+[[Pets]]
+Name = "Tiddles"
+				`)), nil)
+				mySourcer.sources.files = []string{"test.toml"}
+				Expect(mySourcer.Get("Name")).To(Equal("Bob"))
+				Expect(mySourcer.Get("Age")).To(Equal("41"))
+				Expect(mySourcer.Get("Hobbies_Sports_First")).To(Equal("Skating"))
+				Expect(mySourcer.Get("Pets_0_Name")).To(Equal("Rex"))
+				Expect(mySourcer.Get("Pets_1_Name")).To(Equal("Tiddles"))
+			})
+
+			It("should keep numbers which are not finite as text", func() {
+				mockFileReader.EXPECT().Read("test.toml").Return([]byte("[Db]\nRatio = nan\nLimit = inf\n"), nil)
+				mySourcer.Source("test.toml")
+				Expect(mySourcer.Get("Db_Ratio")).To(Equal("NaN"))
+				Expect(mySourcer.Get("Db")).To(Equal(`"Limit":"+Inf","Ratio":"NaN"`))
+			})
+		})
+
+		When("a dotenv file is processed", func() {
+			It("should rank it below environment variables but above other files", func() {
+				mockTerminalReader.EXPECT().Get(gomock.Any()).Return("", errors.New("not_found")).Times(3)
+				os.Setenv("Dotenv_Colour", "Blue")
+				defer os.Unsetenv("Dotenv_Colour")
+				mockFileReader.EXPECT().Read("test.yaml").Return([]byte(strings.TrimSpace(`
+Dotenv:
+  Colour: Red
+  Shape: Square
+  Size: Large
+				`)), nil)
+				mockFileReader.EXPECT().Read(".env").Return([]byte(strings.TrimSpace(`
+Dotenv_Colour=Green
+export Dotenv_Shape="Circle"
+				`)), nil)
+				mySourcer.sources.files = []string{"test.yaml", ".env"}
+				Expect(mySourcer.Get("Dotenv_Colour")).To(Equal("Blue"))
+				Expect(mySourcer.Get("Dotenv_Shape")).To(Equal("Circle"))
+				Expect(mySourcer.Get("Dotenv_Size")).To(Equal("Large"))
+			})
+
+			It("should use values exactly as its quoting rules give them", func() {
+				os.Setenv("DOTENV_X", "expanded")
+				defer os.Unsetenv("DOTENV_X")
+				mockFileReader.EXPECT().Read(".env").Return([]byte(strings.Join([]string{
+					`PASSWORD='abc${DOTENV_X}def'`,
+					`ESCAPED="\${HOME}"`,
+					`EXPANDED="${DOTENV_X}"`,
+				}, "\n")), nil)
+				mySourcer.Source(".env")
+				Expect(mySourcer.Get("PASSWORD")).To(Equal("abc${DOTENV_X}def"))
+				Expect(mySourcer.Get("ESCAPED")).To(Equal("${HOME}"))
+				Expect(mySourcer.Get("EXPANDED")).To(Equal("expanded"))
+				Expect(mySourcer.Explain("PASSWORD").Value).To(Equal("abc${DOTENV_X}def"))
+			})
+
+			It("should keep values from an included dotenv file as written", func() {
+				mockFileReader.EXPECT().Read("app.yml").Return([]byte("include: .env\nUrl: ${Password}"), nil)
+				mockFileReader.EXPECT().Read(".env").Return([]byte(`Password='p@ss${word}'`), nil)
+				mySourcer.Source("app.yml")
+				Expect(mySourcer.Get("Password")).To(Equal("p@ss${word}"))
+				Expect(mySourcer.Get("Url")).To(Equal("p@ss${word}"))
+			})
+		})
+
+		When("a properties file is processed", func() {
+			It("should map dotted keys onto nested values", func() {
+				mockTerminalReader.EXPECT().Get(gomock.Any()).Return("", errors.New("not_found")).Times(4)
+				mockFileReader.EXPECT().Read("application.properties").Return([]byte(strings.TrimSpace(`
+Name=Bob
+Classes.History.Location=Room C4
+Classes.History.Pupils.0.Name=Pete
+Classes.History.Pupils.1.Name=Laura
+				`)), nil)
+				mySourcer.sources.files = []string{"application.properties"}
+				Expect(mySourcer.Get("Name")).To(Equal("Bob"))
+				Expect(mySourcer.Get("Classes_History_Location")).To(Equal("Room C4"))
+				Expect(mySourcer.Get("Classes_History_Pupils_1_Name")).To(Equal("Laura"))
+				Expect(mySourcer.Get("Classes_History_Pupils")).To(Equal(`{"Name":"Pete"},{"Name":"Laura"}`))
+			})
+
+			It("should report a key which holds a value and has keys nested beneath it", func() {
+				mockFileReader.EXPECT().Read("application.properties").Return([]byte(strings.TrimSpace(`
+Server=localhost
+Server.Port=8080
+				`)), nil)
+				mySourcer.sources.files = []string{"application.properties"}
+				Expect(mySourcer.setup()).To(MatchError(
+					"error parsing source file : application.properties : " +
+						"conflicting keys : Server holds a value but Server.Port is nested beneath it",
+				))
+			})
+		})
+
+		When("an ini file is processed", func() {
+			It("should map sections onto nested values", func() {
+				mockTerminalReader.EXPECT().Get(gomock.Any()).Return("", errors.New("not_found")).Times(3)
+				mockFileReader.EXPECT().Read("settings.ini").Return([]byte(strings.TrimSpace(`
+Name = Bob
+
+[Classes.History]
+Location = Room C4
+ClassLength = 3 hours
+				`)), nil)
+				mySourcer.sources.files = []string{"settings.ini"}
+				Expect(mySourcer.Get("Name")).To(Equal("Bob"))
+				Expect(mySourcer.Get("Classes_History_Location")).To(Equal("Room C4"))
+				Expect(mySourcer.Get("Classes_History_ClassLength")).To(Equal("3 hours"))
+			})
+
+			It("should report a section which clashes with a value", func() {
+				mockFileReader.EXPECT().Read("settings.ini").Return([]byte(strings.TrimSpace(`
+Classes = none
+
+[Classes.History]
+Location = Room C4
+				`)), nil)
+				mySourcer.sources.files = []string{"settings.ini"}
+				Expect(mySourcer.setup()).To(MatchError(
+					"error parsing source file : settings.ini : " +
+						"conflicting keys : Classes holds a value but Classes.History.Location is nested beneath it",
+				))
+			})
+		})
+
+		When("a custom format has been registered", func() {
+			It("should use the registered decoder for files with that extension", func() {
+				RegisterFormat(".pairs", func(source []byte) (map[string]interface{}, error) {
+					data := make(map[string]interface{})
+					for _, pair := range strings.Split(string(source), ",") {
+						bits := strings.Split(pair, "|")
+						data[bits[0]] = bits[1]
+					}
+					return data, nil
+				})
+				mockFileReader.EXPECT().Read("test.pairs").Return([]byte(`Name|Bob,Town|York`), nil)
+				mySourcer.Source("test.pairs")
+				Expect(mySourcer.Get("Name")).To(Equal("Bob"))
+				Expect(mySourcer.Get("Town")).To(Equal("York"))
+			})
+
+			It("should key nested maps by text when a decoder keys them by other types", func() {
+				RegisterFormat(".nested", func(source []byte) (map[string]interface{}, error) {
+					return map[string]interface{}{
+						"Ports": map[interface{}]interface{}{80: "http", "tls": 443},
+					}, nil
+				})
+				mockFileReader.EXPECT().Read("test.nested").Return([]byte(``), nil)
+				mySourcer.Source("test.nested")
+				Expect(mySourcer.Get("Ports_80")).To(Equal("http"))
+				Expect(mySourcer.Get("Ports")).To(Equal(`"80":"http","tls":443`))
+			})
+		})
+
+		When("the filename of the source file has no extension but its content is recognisable", func() {
+			It("should work out the format from the content", func() {
+				for _, content := range []string{
+					`{"Name": "Bob", "Hobbies": {"Sports": {"First": "Skating"}}}`,
+					"# yaml\nName: Bob\nHobbies:\n  Sports:\n    First: Skating\n",
+					"Name = \"Bob\"\n[Hobbies.Sports]\nFirst = \"Skating\"\n",
+					"Name=Bob\nHobbies_Sports_First=Skating\n",
+					"Name=Bob\nHobbies.Sports.First=Skating\n",
+				} {
+					mockFileReader.EXPECT().Read("mysource").Return([]byte(content), nil)
+					mySourcer.Source("mysource")
+					Expect(mySourcer.Get("Name")).To(Equal("Bob"))
+					Expect(mySourcer.Get("Hobbies_Sports_First")).To(Equal("Skating"))
+				}
+			})
+		})
+
 		When("a value exists in a file, an enivornment variable and the terminal", func() {
 			It("should prioritise the three sources appropriately", func() {
 				mockTerminalReader.EXPECT().Get("Scores_One").Return("1", nil)
@@ -108,6 +315,909 @@ Name: Bob
 			})
 		})
 
+		When("sources are given explicitly", func() {
+			It("should consult them in the order given", func() {
+				mockTerminalReader.EXPECT().Get("Name").Return("", errors.New("not_found"))
+				mockTerminalReader.EXPECT().Get("Age").Return("", errors.New("not_found"))
+				mockTerminalReader.EXPECT().Get("Town").Return("Leeds", nil)
+				mockFileReader.EXPECT().Read("test.yaml").Return([]byte(strings.TrimSpace(`
+Name: Bob
+Age: 41
+Town: York
+				`)), nil)
+				mySourcer.sources.chain = []Source{
+					terminalSource{reader: mockTerminalReader},
+					mapSource{"Age": "50"},
+					fileSource{path: "test.yaml", reader: mockFileReader},
+				}
+				Expect(mySourcer.Get("Name")).To(Equal("Bob"))
+				Expect(mySourcer.Get("Age")).To(Equal("50"))
+				Expect(mySourcer.Get("Town")).To(Equal("Leeds"))
+			})
+
+			It("should give other sources keys without escaped separators", func() {
+				mySourcer.sources.chain = []Source{mapSource{"Db_max_idle_conns": "5"}}
+				Expect(mySourcer.Get(`Db_max\_idle\_conns`)).To(Equal("5"))
+			})
+
+			It("should not consult sources which were left out", func() {
+				os.Setenv("Colour", "Blue")
+				mySourcer.sources.chain = []Source{mapSource{"Name": "Bob"}}
+				Expect(mySourcer.Get("Name")).To(Equal("Bob"))
+				Expect(mySourcer.Get("Colour")).To(Equal(""))
+			})
+		})
+
+		When("keys differ in case from the names requested", func() {
+			BeforeEach(func() {
+				os.Setenv("CASE_COLOUR", "Blue")
+				mockFileReader.EXPECT().Read("test.yaml").Return([]byte(strings.TrimSpace(`
+name: Bob
+hobbies:
+  SPORTS:
+    first: Skating
+shape: Square
+SHAPE: Circle
+Shape: Triangle
+				`)), nil)
+				mySourcer.sources.chain = []Source{
+					environmentSource{},
+					fileSource{path: "test.yaml", reader: mockFileReader},
+				}
+			})
+
+			AfterEach(func() {
+				os.Unsetenv("CASE_COLOUR")
+			})
+
+			It("should match environment variables and file keys regardless of case", func() {
+				Expect(mySourcer.Get("Case_Colour")).To(Equal("Blue"))
+				Expect(mySourcer.Get("Name")).To(Equal("Bob"))
+				Expect(mySourcer.Get("Hobbies_Sports_First")).To(Equal("Skating"))
+			})
+
+			It("should prefer an exact match, then the first matching key in sorted order", func() {
+				Expect(mySourcer.Get("Shape")).To(Equal("Triangle"))
+				Expect(mySourcer.Get("shape")).To(Equal("Square"))
+				for x := 0; x < 20; x++ {
+					Expect(mySourcer.Get("sHAPE")).To(Equal("Circle"))
+				}
+			})
+
+			It("should only match exactly when a literal match is requested", func() {
+				literal := structs.LookupOptions{Literal: true}
+				Expect(mySourcer.Get("Case_Colour", literal)).To(Equal(""))
+				Expect(mySourcer.Get("CASE_COLOUR", literal)).To(Equal("Blue"))
+				Expect(mySourcer.Get("Name", literal)).To(Equal(""))
+				Expect(mySourcer.Get("hobbies_SPORTS_first", literal)).To(Equal("Skating"))
+			})
+		})
+
+		When("keys contain the separator", func() {
+			BeforeEach(func() {
+				mockFileReader.EXPECT().Read("test.yaml").Return([]byte(strings.TrimSpace(`
+Db:
+  max_connections: 20
+  max:
+    connections: 10
+  Replicas:
+    - host_name: replica-1
+				`)), nil)
+				mySourcer.sources.chain = []Source{fileSource{path: "test.yaml", reader: mockFileReader}}
+			})
+
+			It("should prefer the longest key which matches", func() {
+				Expect(mySourcer.Get("Db_Max_Connections")).To(Equal("20"))
+				Expect(mySourcer.Get("Db_Replicas_0_Host_Name")).To(Equal("replica-1"))
+			})
+
+			It("should treat an escaped separator as part of the name", func() {
+				Expect(mySourcer.Get(`Db_max\_connections`)).To(Equal("20"))
+			})
+
+			It("should split on a configured separator", func() {
+				mySourcer.separator = "."
+				Expect(mySourcer.Get("Db.max_connections")).To(Equal("20"))
+				Expect(mySourcer.Get("Db.max.connections")).To(Equal("10"))
+				Expect(mySourcer.Get(`Db.Max\.Connections`)).To(Equal(""))
+				Expect(mySourcer.Get("Db.MAX_CONNECTIONS", structs.LookupOptions{Literal: true})).To(Equal(""))
+				Expect(mySourcer.Get("Db.Replicas.0.host_name")).To(Equal("replica-1"))
+			})
+		})
+
+		When("a configured separator is used with environment variables", func() {
+			It("should join the levels of nesting with underscores", func() {
+				os.Setenv("SEPARATOR_DB_MAX_CONNECTIONS", "30")
+				defer os.Unsetenv("SEPARATOR_DB_MAX_CONNECTIONS")
+				mySourcer.separator = "."
+				mySourcer.sources.chain = []Source{environmentSource{}}
+				Expect(mySourcer.Get("Separator.Db.max_connections")).To(Equal("30"))
+			})
+		})
+
+		When("names are given for each kind of source", func() {
+			options := structs.LookupOptions{Env: "DATABASE_URL", Flag: "db-url", File: "database.url"}
+
+			BeforeEach(func() {
+				os.Setenv("DATABASE_URL", "postgres://env")
+				os.Setenv("Db_Url", "postgres://default")
+				mockFileReader.EXPECT().Read("test.yaml").Return([]byte(strings.TrimSpace(`
+database:
+  url: postgres://file
+				`)), nil)
+				mySourcer.separator = "."
+			})
+
+			AfterEach(func() {
+				os.Unsetenv("DATABASE_URL")
+				os.Unsetenv("Db_Url")
+			})
+
+			It("should read the terminal argument named by the flag", func() {
+				mySourcer.sources.chain = []Source{
+					terminalSource{reader: mockTerminalReader},
+					environmentSource{prefix: "MYAPP_"},
+					fileSource{path: "test.yaml", reader: mockFileReader},
+				}
+				mockTerminalReader.EXPECT().Get("db-url").Return("postgres://flag", nil)
+				Expect(mySourcer.Get("Db.Url", options)).To(Equal("postgres://flag"))
+			})
+
+			It("should read the environment variable named by the env tag regardless of the prefix", func() {
+				mySourcer.sources.chain = []Source{
+					terminalSource{reader: mockTerminalReader},
+					environmentSource{prefix: "MYAPP_"},
+					fileSource{path: "test.yaml", reader: mockFileReader},
+				}
+				mockTerminalReader.EXPECT().Get("db-url").Return("", errors.New("not_found"))
+				Expect(mySourcer.Get("Db.Url", options)).To(Equal("postgres://env"))
+			})
+
+			It("should read the file key named by the file tag", func() {
+				mySourcer.sources.chain = []Source{fileSource{path: "test.yaml", reader: mockFileReader}}
+				Expect(mySourcer.Get("Db.Url", options)).To(Equal("postgres://file"))
+				Expect(mySourcer.Get("Db.Url")).To(Equal(""))
+			})
+		})
+
+		When("an environment prefix is set", func() {
+			BeforeEach(func() {
+				os.Setenv("PORT", "80")
+				os.Setenv("MYAPP_PORT", "8080")
+				mySourcer.sources.envPrefix = "MYAPP_"
+				mySourcer.sources.useCommandLine = false
+				mySourcer.sources.files = nil
+			})
+
+			AfterEach(func() {
+				os.Unsetenv("PORT")
+				os.Unsetenv("MYAPP_PORT")
+			})
+
+			It("should only read variables carrying the prefix", func() {
+				Expect(mySourcer.Get("Port")).To(Equal("8080"))
+				Expect(environmentSource{prefix: "MYAPP_"}.Keys()).To(ContainElement("PORT"))
+				Expect(environmentSource{prefix: "MYAPP_"}.Keys()).ToNot(ContainElement("MYAPP_PORT"))
+			})
+		})
+
+		When("a directory of files is given as a source", func() {
+			It("should treat file names as keys and their contents as values", func() {
+				dir, err := os.MkdirTemp("", "secrets")
+				Expect(err).ToNot(HaveOccurred())
+				defer os.RemoveAll(dir)
+
+				// mimic the kubernetes layout, where visible files link into a hidden ..data directory
+				Expect(os.MkdirAll(filepath.Join(dir, "..data", "db"), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(dir, "..data", "db", "password"), []byte("s3cret\n"), 0644)).To(Succeed())
+				Expect(os.Symlink(filepath.Join("..data", "db"), filepath.Join(dir, "db"))).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(dir, "Name"), []byte("Bob"), 0644)).To(Succeed())
+
+				mySourcer.sources.chain = []Source{
+					mapSource{"Name": "Overridden"},
+					directorySource{path: dir, reader: fileReader.New()},
+					mapSource{"Db_Password": "default", "Db_User": "admin"},
+				}
+				Expect(mySourcer.Get("Name")).To(Equal("Overridden"))
+				Expect(mySourcer.Get("Db_Password")).To(Equal("s3cret"))
+				Expect(mySourcer.Get("Db_User")).To(Equal("admin"))
+				Expect(mySourcer.Get("data_db_password")).To(Equal(""))
+			})
+
+			It("should use the contents of files exactly as written", func() {
+				dir, err := os.MkdirTemp("", "secrets")
+				Expect(err).ToNot(HaveOccurred())
+				defer os.RemoveAll(dir)
+				Expect(os.MkdirAll(filepath.Join(dir, "db"), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(dir, "db", "password"), []byte("p@ss${word}"), 0644)).To(Succeed())
+
+				failures := make([]error, 0)
+				mySourcer.OnError(func(err error) {
+					failures = append(failures, err)
+				})
+				mySourcer.sources.chain = []Source{directorySource{path: dir, reader: fileReader.New()}}
+				Expect(mySourcer.Get("Db_Password")).To(Equal("p@ss${word}"))
+				Expect(mySourcer.Get("Db")).To(Equal(`"password":"p@ss${word}"`))
+				Expect(failures).To(BeEmpty())
+			})
+
+			It("should skip the directory if it does not exist", func() {
+				mySourcer.sources.chain = []Source{
+					directorySource{path: "/does/not/exist", reader: fileReader.New()},
+					mapSource{"Name": "Bob"},
+				}
+				Expect(mySourcer.Get("Name")).To(Equal("Bob"))
+			})
+		})
+
+		When("a drop-in directory is given as a source", func() {
+			It("should merge the fragments in lexical order and report which supplied each value", func() {
+				dir, err := os.MkdirTemp("", "config.d")
+				Expect(err).ToNot(HaveOccurred())
+				defer os.RemoveAll(dir)
+
+				Expect(os.WriteFile(filepath.Join(dir, "10-defaults.yml"), []byte("Name: Bob\nPort: 80\nHost: localhost\n"), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(dir, "20-network.json"), []byte(`{"Port": "8080"}`), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(dir, "30-notes.md"), []byte("Port: 1"), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(dir, ".99-hidden.yml"), []byte("Port: 2"), 0644)).To(Succeed())
+
+				mySourcer.sources.chain = []Source{
+					mapSource{"Host": "example.com"},
+					dropInSource{path: dir, reader: fileReader.New()},
+				}
+				Expect(mySourcer.Get("Name")).To(Equal("Bob"))
+				Expect(mySourcer.Origin("Name")).To(Equal(filepath.Join(dir, "10-defaults.yml")))
+				Expect(mySourcer.Get("Port")).To(Equal("8080"))
+				Expect(mySourcer.Origin("Port")).To(Equal(filepath.Join(dir, "20-network.json")))
+				Expect(mySourcer.Get("Host")).To(Equal("example.com"))
+				Expect(mySourcer.Origin("Host")).To(Equal("map"))
+				Expect(mySourcer.Origin("Unknown")).To(Equal(""))
+			})
+		})
+
+		When("the provenance of values is requested", func() {
+			BeforeEach(func() {
+				os.Setenv("PROVENANCE_PORT", "80")
+				mockFileReader.EXPECT().Read("test.yaml").Return([]byte(strings.TrimSpace(`
+Provenance:
+  Host: localhost
+  Port: 8080
+  Name: ${PROVENANCE_USER:-bob}
+				`)), nil)
+				mySourcer.sources.chain = []Source{
+					terminalSource{reader: mockTerminalReader},
+					environmentSource{},
+					fileSource{path: "test.yaml", reader: mockFileReader},
+					mapSource{"Provenance_Host": "example.com"},
+				}
+			})
+
+			AfterEach(func() {
+				os.Unsetenv("PROVENANCE_PORT")
+			})
+
+			It("should report the winning source and those it overrides", func() {
+				mockTerminalReader.EXPECT().Get("PROVENANCE_USER").Return("", errors.New("not_found"))
+				mockTerminalReader.EXPECT().Get("Provenance_Port").Return("9090", nil)
+				explanation := mySourcer.Explain("Provenance_Port")
+				Expect(explanation.Value).To(Equal("9090"))
+				Expect(*explanation.Source).To(Equal(structs.Candidate{Name: "command line", Location: "--Provenance_Port", Value: "9090"}))
+				Expect(explanation.Overridden).To(Equal([]structs.Candidate{
+					{Name: "environment", Location: "$PROVENANCE_PORT", Value: "80"},
+					{Name: "test.yaml", Location: "test.yaml:3", Value: "8080"},
+				}))
+				Expect(explanation.String()).To(Equal("Provenance_Port = 9090 from --Provenance_Port, overriding 80 from $PROVENANCE_PORT, overriding 8080 from test.yaml:3"))
+			})
+
+			It("should report the value after references are expanded", func() {
+				mockTerminalReader.EXPECT().Get(gomock.Any()).Return("", errors.New("not_found")).AnyTimes()
+				explanation := mySourcer.Explain("Provenance_Name")
+				Expect(explanation.Value).To(Equal("bob"))
+				Expect(explanation.Source.Value).To(Equal("${PROVENANCE_USER:-bob}"))
+				Expect(explanation.Source.Location).To(Equal("test.yaml:4"))
+			})
+
+			It("should include struct defaults beneath every source", func() {
+				mockTerminalReader.EXPECT().Get(gomock.Any()).Return("", errors.New("not_found")).AnyTimes()
+				mySourcer.Default("Provenance_Timeout", "30s")
+				mySourcer.Default("Provenance_Host", "127.0.0.1")
+
+				explanation := mySourcer.Explain("Provenance_Timeout")
+				Expect(explanation.Value).To(Equal("30s"))
+				Expect(explanation.Source.Location).To(Equal("default"))
+
+				explanation = mySourcer.Explain("Provenance_Host")
+				Expect(explanation.Source.Location).To(Equal("test.yaml:2"))
+				Expect(explanation.Overridden).To(Equal([]structs.Candidate{
+					{Name: "map", Location: "map", Value: "example.com"},
+					{Name: "default", Location: "default", Value: "127.0.0.1"},
+				}))
+			})
+
+			It("should merge the value of every key into nested values", func() {
+				mockTerminalReader.EXPECT().Keys().Return([]string{"Verbose"})
+				mockTerminalReader.EXPECT().Get("Verbose").Return("1", nil)
+				mockTerminalReader.EXPECT().Get(gomock.Any()).Return("", errors.New("not_found")).AnyTimes()
+				mySourcer.Default("Provenance_Timeout", "30s")
+
+				Expect(mySourcer.Values()).To(Equal(map[string]interface{}{
+					"Provenance": map[string]interface{}{
+						"Host":    "localhost",
+						"Port":    "80",
+						"Name":    "bob",
+						"Timeout": "30s",
+					},
+					"Verbose": "1",
+				}))
+			})
+
+			It("should look values up with the options recorded for them", func() {
+				mockTerminalReader.EXPECT().Get("PROVENANCE_USER").Return("", errors.New("not_found"))
+				mockTerminalReader.EXPECT().Get("listen-port").Return("", errors.New("not_found")).AnyTimes()
+				mySourcer.LookupOptions("Listen_Port", structs.LookupOptions{
+					Env:  "PROVENANCE_PORT",
+					Flag: "listen-port",
+					File: "Provenance_Port",
+				})
+
+				explanation := mySourcer.Explain("Listen_Port")
+				Expect(explanation.String()).To(Equal("Listen_Port = 80 from $PROVENANCE_PORT, overriding 8080 from test.yaml:3"))
+				Expect(mySourcer.Explain("listen_port").Value).To(Equal("80"))
+			})
+
+			It("should redact secrets", func() {
+				mockTerminalReader.EXPECT().Keys().Return(nil)
+				mockTerminalReader.EXPECT().Get(gomock.Any()).Return("", errors.New("not_found")).AnyTimes()
+				mySourcer.Redact("provenance_port")
+
+				explanation := mySourcer.Explain("Provenance_Port")
+				Expect(explanation.String()).To(Equal("Provenance_Port = [REDACTED] from $PROVENANCE_PORT, overriding [REDACTED] from test.yaml:3"))
+				Expect(mySourcer.Values()["Provenance"]).To(HaveKeyWithValue("Port", "[REDACTED]"))
+				Expect(mySourcer.Get("Provenance_Port")).To(Equal("80"))
+			})
+
+			It("should explain every key held outside the environment", func() {
+				mockTerminalReader.EXPECT().Keys().Return([]string{"Verbose"})
+				mockTerminalReader.EXPECT().Get(gomock.Any()).Return("", errors.New("not_found")).AnyTimes()
+				provenance := mySourcer.Provenance()
+				Expect(provenance).To(HaveLen(4))
+				Expect(provenance).To(HaveKey("Provenance_Host"))
+				Expect(provenance).To(HaveKey("Verbose"))
+				Expect(provenance["Provenance_Port"].Source.Location).To(Equal("$PROVENANCE_PORT"))
+				Expect(mySourcer.Explain("Unknown").String()).To(Equal("Unknown is not set"))
+			})
+		})
+
+		When("a value refers to a secret", func() {
+			BeforeEach(func() {
+				mockFileReader.EXPECT().Read("test.yaml").Return([]byte(strings.TrimSpace(`
+Db:
+  Password: hunter2
+  Url: postgres://u:${Db_Password}@h/accounts
+  Backup: ${Db_Url}
+  Host: h
+Loop: ${Loop}
+				`)), nil)
+				mySourcer.sources.chain = []Source{fileSource{path: "test.yaml", reader: mockFileReader}}
+				mySourcer.Redact("DB_PASSWORD")
+			})
+
+			It("should redact the expanded value, however deeply the secret is referred to", func() {
+				Expect(mySourcer.Explain("Db_Url").Value).To(Equal("[REDACTED]"))
+				Expect(mySourcer.Explain("Db_Url").Source.Value).To(Equal("postgres://u:${Db_Password}@h/accounts"))
+				Expect(mySourcer.Explain("Db_Backup").Value).To(Equal("[REDACTED]"))
+				Expect(mySourcer.Explain("Loop").Value).To(Equal("${Loop}"))
+				Expect(mySourcer.Values()).To(Equal(map[string]interface{}{
+					"Db": map[string]interface{}{
+						"Password": "[REDACTED]",
+						"Url":      "[REDACTED]",
+						"Backup":   "[REDACTED]",
+						"Host":     "h",
+					},
+					"Loop": "${Loop}",
+				}))
+				Expect(mySourcer.Get("Db_Url")).To(Equal("postgres://u:hunter2@h/accounts"))
+			})
+		})
+
+		When("a file includes other files", func() {
+			BeforeEach(func() {
+				mySourcer.UseOverrides(false)
+			})
+
+			It("should merge the included files beneath the including file", func() {
+				mockFileReader.EXPECT().Read("config/app.yml").Return([]byte(strings.TrimSpace(`
+include:
+  - common/database.yml
+  - common/network.json
+Database:
+  Name: accounts
+				`)), nil)
+				mockFileReader.EXPECT().Read("config/common/database.yml").Return([]byte(strings.TrimSpace(`
+$include: defaults.toml
+Database:
+  Host: db.internal
+  Name: postgres
+				`)), nil)
+				mockFileReader.EXPECT().Read("config/common/defaults.toml").Return([]byte(strings.TrimSpace(`
+[Database]
+Port = 5432
+Host = "localhost"
+				`)), nil)
+				mockFileReader.EXPECT().Read("config/common/network.json").Return([]byte(`{"Port": 8080}`), nil)
+				mySourcer.SourceFiles([]structs.SourceFile{{Path: "config/app.yml", Required: true}})
+				Expect(mySourcer.Get("Database_Name")).To(Equal("accounts"))
+				Expect(mySourcer.Get("Database_Host")).To(Equal("db.internal"))
+				Expect(mySourcer.Get("Database_Port")).To(Equal("5432"))
+				Expect(mySourcer.Get("Port")).To(Equal("8080.000000"))
+				Expect(mySourcer.Get("include")).To(Equal(""))
+			})
+
+			It("should name the chain of includes when an included file is missing", func() {
+				mockFileReader.EXPECT().Read("app.yml").Return([]byte("include: common.yml\nName: Bob"), nil)
+				mockFileReader.EXPECT().Read("common.yml").Return([]byte("include: missing.yml"), nil)
+				mockFileReader.EXPECT().Read("missing.yml").Return(nil, errors.New("file not found"))
+				mySourcer.SourceFiles([]structs.SourceFile{{Path: "app.yml"}})
+				Expect(mySourcer.setup()).To(MatchError("could not read included file : missing.yml : included by app.yml -> common.yml"))
+			})
+
+			It("should keep the error rather than reading the files again for every lookup", func() {
+				mockFileReader.EXPECT().Read("app.yml").Return([]byte("include: missing.yml\nName: Bob"), nil)
+				mockFileReader.EXPECT().Read("missing.yml").Return(nil, errors.New("file not found"))
+				mySourcer.SourceFiles([]structs.SourceFile{{Path: "app.yml"}})
+				Expect(mySourcer.Get("Name")).To(Equal(""))
+				Expect(mySourcer.Get("Name")).To(Equal(""))
+				Expect(mySourcer.Err()).To(MatchError("could not read included file : missing.yml : included by app.yml"))
+
+				mockFileReader.EXPECT().Read("app.yml").Return([]byte("Name: Bob"), nil)
+				Expect(mySourcer.Reload()).To(BeNil())
+				Expect(mySourcer.Get("Name")).To(Equal("Bob"))
+			})
+
+			It("should detect include cycles", func() {
+				mockFileReader.EXPECT().Read("app.yml").Return([]byte("include: common.yml"), nil)
+				mockFileReader.EXPECT().Read("common.yml").Return([]byte("include: ./app.yml"), nil)
+				mySourcer.SourceFiles([]structs.SourceFile{{Path: "app.yml"}})
+				Expect(mySourcer.setup()).To(MatchError("include cycle detected : app.yml -> common.yml -> app.yml"))
+			})
+		})
+
+		When("a value refers to other values", func() {
+			var (
+				failures []error
+				content  string
+			)
+
+			BeforeEach(func() {
+				failures = make([]error, 0)
+				mySourcer.OnError(func(err error) {
+					failures = append(failures, err)
+				})
+				os.Setenv("INTERPOLATION_USER", "bob")
+				os.Setenv("INTERPOLATION_RAW", "ab${cd")
+				content = strings.TrimSpace(`
+Url: postgres://${INTERPOLATION_USER}@${Database_Host}:${Database_Port:-5432}/${Database_Name}
+Database:
+  Host: ${Database_Server:-localhost}
+  Name: ${Database_Prefix:-live}_accounts
+Template: ${Interpolation_Template}
+				`)
+				mySourcer.sources.chain = []Source{
+					environmentSource{},
+					mapSource{"Interpolation_Template": "${Database_Host}"},
+					fileSource{path: "test.yaml", reader: mockFileReader},
+				}
+			})
+
+			JustBeforeEach(func() {
+				mockFileReader.EXPECT().Read("test.yaml").Return([]byte(content), nil)
+			})
+
+			AfterEach(func() {
+				os.Unsetenv("INTERPOLATION_USER")
+				os.Unsetenv("INTERPOLATION_RAW")
+			})
+
+			It("should expand references to environment variables and other keys", func() {
+				Expect(mySourcer.Get("Url")).To(Equal("postgres://bob@localhost:5432/live_accounts"))
+				Expect(failures).To(BeEmpty())
+			})
+
+			It("should use values from sources other than files exactly as given", func() {
+				Expect(mySourcer.Get("INTERPOLATION_RAW")).To(Equal("ab${cd"))
+				Expect(mySourcer.Get("Interpolation_Template")).To(Equal("${Database_Host}"))
+				Expect(mySourcer.Get("Template")).To(Equal("${Database_Host}"))
+				Expect(failures).To(BeEmpty())
+			})
+
+			When("the references cannot be resolved", func() {
+				BeforeEach(func() {
+					content += "\nLoop:\n  First: ${Loop_Second}\n  Second: ${Loop_First}\nDb:\n  Password: ab${cd"
+				})
+
+				It("should keep the values as written and report each of them once when the sources are loaded", func() {
+					for x := 0; x < 3; x++ {
+						Expect(mySourcer.Get("Loop_First")).To(Equal("${Loop_Second}"))
+						Expect(mySourcer.Get("Db_Password")).To(Equal("ab${cd"))
+						mySourcer.Explain("Db")
+					}
+					Expect(failures).To(ConsistOf(
+						MatchError("could not expand the value of Db_Password : unterminated reference starting at character 3"),
+						MatchError("could not expand the value of Loop_First : "+
+							"interpolation cycle detected : Loop_First -> Loop_Second -> Loop_First"),
+						MatchError("could not expand the value of Loop_Second : "+
+							"interpolation cycle detected : Loop_Second -> Loop_First -> Loop_Second"),
+					))
+				})
+
+				It("should tell callbacks registered later about the sources already loaded", func() {
+					Expect(mySourcer.setup()).To(Succeed())
+					late := make([]error, 0)
+					mySourcer.OnError(func(err error) {
+						late = append(late, err)
+					})
+					Expect(late).To(HaveLen(3))
+					Expect(failures).To(HaveLen(3))
+				})
+
+				It("should let callbacks look values up", func() {
+					looked := 0
+					mySourcer.OnError(func(err error) {
+						looked++
+						mySourcer.Explain("Loop_First")
+						mySourcer.Get("Db_Password")
+					})
+					Expect(mySourcer.Get("Url")).To(Equal("postgres://bob@localhost:5432/live_accounts"))
+					Expect(looked).To(Equal(3))
+					Expect(mySourcer.Get("Loop_First")).To(Equal("${Loop_Second}"))
+					Expect(looked).To(Equal(3))
+				})
+			})
+
+			When("a nested value refers to other values", func() {
+				BeforeEach(func() {
+					os.Setenv("INTERPOLATION_QUOTED", `say "hi"`)
+					content += "\nLabels:\n  Team: ${INTERPOLATION_QUOTED}\n  Tier: one"
+				})
+
+				AfterEach(func() {
+					os.Unsetenv("INTERPOLATION_QUOTED")
+				})
+
+				It("should resolve each text value on its own", func() {
+					Expect(mySourcer.Get("Labels")).To(Equal(`"Team":"say \"hi\"","Tier":"one"`))
+					Expect(mySourcer.Values()["Labels"]).To(Equal(map[string]interface{}{"Team": `say "hi"`, "Tier": "one"}))
+					Expect(failures).To(BeEmpty())
+				})
+			})
+		})
+
+		When("the sources are reloaded", func() {
+			var changes [][]string
+
+			BeforeEach(func() {
+				changes = make([][]string, 0)
+				mySourcer.UseOverrides(false)
+				mySourcer.SourceFiles([]structs.SourceFile{{Path: "app.yml", Required: true}})
+				mySourcer.OnChange(func(changedKeys []string) {
+					changes = append(changes, changedKeys)
+				})
+				mockFileReader.EXPECT().Read("app.yml").Return([]byte("Port: 8080\nHost: localhost\nDebug: true"), nil)
+			})
+
+			It("should swap in the new values and report the keys which changed", func() {
+				mockFileReader.EXPECT().Read("app.yml").Return([]byte("Port: 9090\nHost: localhost\nName: api"), nil)
+				Expect(mySourcer.Get("Port")).To(Equal("8080"))
+				Expect(mySourcer.Reload()).To(BeNil())
+				Expect(mySourcer.Get("Port")).To(Equal("9090"))
+				Expect(mySourcer.Get("Debug")).To(Equal(""))
+				Expect(changes).To(Equal([][]string{{"Debug", "Name", "Port"}}))
+			})
+
+			It("should report the keys which changed when the sources are replaced", func() {
+				mockFileReader.EXPECT().Read("other.yml").Return([]byte("Port: 9090\nHost: localhost"), nil)
+				Expect(mySourcer.Get("Port")).To(Equal("8080"))
+				mySourcer.Source("other.yml")
+				Expect(mySourcer.Get("Port")).To(Equal("9090"))
+				Expect(changes).To(Equal([][]string{{"Debug", "Port"}}))
+			})
+
+			It("should drop the current values when replaced sources cannot be read, and report why", func() {
+				failures := make([]error, 0)
+				mySourcer.OnError(func(err error) {
+					failures = append(failures, err)
+				})
+				mockFileReader.EXPECT().Read("other.yml").Return(nil, errors.New("permission denied"))
+				Expect(mySourcer.Get("Port")).To(Equal("8080"))
+				mySourcer.Source("other.yml")
+				Expect(mySourcer.Get("Port")).To(Equal(""))
+				Expect(mySourcer.Get("Host")).To(Equal(""))
+				Expect(mySourcer.Err()).ToNot(BeNil())
+				Expect(changes).To(Equal([][]string{{"Debug", "Host", "Port"}}))
+				Expect(failures).To(HaveLen(1))
+
+				mockFileReader.EXPECT().Read("other.yml").Return([]byte("Port: 9090"), nil)
+				Expect(mySourcer.Reload()).To(BeNil())
+				Expect(mySourcer.Err()).To(BeNil())
+				Expect(mySourcer.Get("Port")).To(Equal("9090"))
+				Expect(changes).To(Equal([][]string{{"Debug", "Host", "Port"}, {"Port"}}))
+			})
+
+			It("should keep the current values when a validator rejects replaced sources", func() {
+				mySourcer.Validate(func(lookup structs.Lookup) (func(), error) {
+					if lookup.Get("Port") == "80" {
+						return nil, errors.New("port 80 is reserved")
+					}
+					return nil, nil
+				})
+				mockFileReader.EXPECT().Read("other.yml").Return([]byte("Port: 80"), nil)
+				Expect(mySourcer.Get("Port")).To(Equal("8080"))
+				mySourcer.Source("other.yml")
+				Expect(mySourcer.Get("Port")).To(Equal("8080"))
+				Expect(changes).To(BeEmpty())
+			})
+
+			It("should keep the current values when a file can no longer be parsed, and report why", func() {
+				failures := make([]error, 0)
+				mySourcer.OnError(func(err error) {
+					failures = append(failures, err)
+				})
+				mockFileReader.EXPECT().Read("app.yml").Return([]byte("Port: [8080"), nil)
+				Expect(mySourcer.Get("Port")).To(Equal("8080"))
+				Expect(mySourcer.Reload()).ToNot(BeNil())
+				Expect(mySourcer.Get("Port")).To(Equal("8080"))
+				Expect(changes).To(BeEmpty())
+				Expect(failures).To(HaveLen(1))
+				Expect(failures[0].Error()).To(HavePrefix("error parsing source file : app.yml"))
+			})
+
+			It("should keep the current values when a validator rejects the new ones", func() {
+				failures := make([]error, 0)
+				committed := false
+				mySourcer.OnError(func(err error) {
+					failures = append(failures, err)
+				})
+				mySourcer.Validate(func(lookup structs.Lookup) (func(), error) {
+					return func() {
+						committed = true
+					}, nil
+				})
+				mySourcer.Validate(func(lookup structs.Lookup) (func(), error) {
+					if lookup.Get("Port") == "80" {
+						return nil, errors.New("port 80 is reserved")
+					}
+					return nil, nil
+				})
+				mockFileReader.EXPECT().Read("app.yml").Return([]byte("Port: 80\nHost: localhost"), nil)
+				Expect(mySourcer.Get("Port")).To(Equal("8080"))
+				Expect(mySourcer.Reload()).To(MatchError("port 80 is reserved"))
+				Expect(mySourcer.Get("Port")).To(Equal("8080"))
+				Expect(failures).To(Equal([]error{errors.New("port 80 is reserved")}))
+				Expect(committed).To(BeFalse())
+				Expect(changes).To(BeEmpty())
+			})
+
+			It("should commit once every validator accepts the new values", func() {
+				committed := ""
+				mySourcer.Validate(func(lookup structs.Lookup) (func(), error) {
+					seen := lookup.Get("Port")
+					return func() {
+						committed = seen + " " + mySourcer.Get("Port")
+					}, nil
+				})
+				mockFileReader.EXPECT().Read("app.yml").Return([]byte("Port: 9090\nHost: localhost\nDebug: true"), nil)
+				Expect(mySourcer.Get("Port")).To(Equal("8080"))
+				Expect(mySourcer.Reload()).To(BeNil())
+				Expect(committed).To(Equal("9090 9090"))
+				Expect(changes).To(Equal([][]string{{"Port"}}))
+			})
+
+			It("should not call the callbacks when nothing changed", func() {
+				mockFileReader.EXPECT().Read("app.yml").Return([]byte("Host: localhost\nDebug: true\nPort: 8080"), nil).Times(2)
+				Expect(mySourcer.Reload()).To(BeNil())
+				Expect(mySourcer.Reload()).To(BeNil())
+				Expect(changes).To(BeEmpty())
+			})
+
+			It("should watch every file read, including files they include, and reload when one changes", func() {
+				var (
+					paths   func() []string
+					changed func()
+				)
+				mockFileWatcher := mocks.NewMockFileWatcher(mockController)
+				mySourcer.watcher = mockFileWatcher
+				mockFileWatcher.EXPECT().Watch(gomock.Any(), gomock.Any(), gomock.Any()).Do(func(ctx context.Context, p func() []string, c func()) {
+					paths, changed = p, c
+				})
+				mySourcer.sources.chain = []Source{
+					fileSource{path: "app.yml", reader: mockFileReader},
+					directorySource{path: "missing.d", reader: mockFileReader},
+				}
+				mockFileReader.EXPECT().Read("common.yml").Return([]byte("Host: db.internal"), nil)
+				mockFileReader.EXPECT().Read("app.yml").Return([]byte("include: common.yml\nPort: 9090"), nil)
+
+				Expect(mySourcer.Watch(context.Background())).To(BeNil())
+				Expect(paths()).To(ConsistOf("app.yml", "missing.d"))
+				Expect(mySourcer.Get("Port")).To(Equal("8080"))
+
+				changed()
+				Expect(mySourcer.Get("Port")).To(Equal("9090"))
+				Expect(paths()).To(ConsistOf("app.yml", "common.yml", "missing.d"))
+				Expect(changes).To(Equal([][]string{{"Debug", "Host", "Port"}}))
+			})
+
+			It("should only run one watch at a time", func() {
+				mockFileWatcher := mocks.NewMockFileWatcher(mockController)
+				mySourcer.watcher = mockFileWatcher
+				mockFileWatcher.EXPECT().Watch(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+
+				ctx, cancel := context.WithCancel(context.Background())
+				Expect(mySourcer.Watch(ctx)).To(BeNil())
+				Expect(mySourcer.Watch(context.Background())).To(MatchError(ErrorAlreadyWatching))
+
+				cancel()
+				Eventually(func() error {
+					return mySourcer.Watch(context.Background())
+				}).Should(BeNil())
+			})
+		})
+
+		When("the sourcer is used from many goroutines", func() {
+			It("should always answer from a complete set of sources while they are reloaded and changed", func() {
+				dir, err := os.MkdirTemp("", "sourcer")
+				Expect(err).To(BeNil())
+				defer os.RemoveAll(dir)
+				path := filepath.Join(dir, "app.yml")
+				write := func(content string) {
+					Expect(os.WriteFile(path+".tmp", []byte(content), 0644)).To(BeNil())
+					Expect(os.Rename(path+".tmp", path)).To(BeNil())
+				}
+				write("Port: 8080\nHost: localhost")
+
+				mySourcer.readers.file = fileReader.New()
+				mySourcer.UseOverrides(false)
+				mySourcer.SourceFiles([]structs.SourceFile{{Path: path, Required: true}})
+
+				var wait sync.WaitGroup
+				unexpected := make(chan string, 1000)
+				run := func(task func(x int)) {
+					wait.Add(1)
+					go func() {
+						defer wait.Done()
+						for x := 0; x < 100; x++ {
+							task(x)
+						}
+					}()
+				}
+
+				for reader := 0; reader < 4; reader++ {
+					run(func(x int) {
+						if port := mySourcer.Get("Port"); port != "8080" && port != "9090" {
+							unexpected <- "port " + port
+						}
+						if host := mySourcer.Get("Host"); host != "localhost" && host != "db.internal" {
+							unexpected <- "host " + host
+						}
+						values := mySourcer.Values()
+						pair := format(values["Port"]) + " " + format(values["Host"])
+						if pair != "8080 localhost" && pair != "9090 db.internal" {
+							unexpected <- "values " + pair
+						}
+						mySourcer.Explain("Port")
+						mySourcer.Provenance()
+						mySourcer.Origin("Host")
+					})
+				}
+				run(func(x int) {
+					if x%2 == 0 {
+						write("Port: 9090\nHost: db.internal")
+					} else {
+						write("Port: 8080\nHost: localhost")
+					}
+					mySourcer.Reload()
+				})
+				run(func(x int) {
+					mySourcer.SourceFiles([]structs.SourceFile{{Path: path, Required: true}})
+					mySourcer.UseOverrides(false)
+				})
+				run(func(x int) {
+					mySourcer.Default("Timeout", "5")
+					mySourcer.Redact("Password")
+					mySourcer.OnChange(func(changedKeys []string) {})
+					mySourcer.OnError(func(err error) {})
+				})
+				wait.Wait()
+
+				close(unexpected)
+				Expect(unexpected).To(BeEmpty())
+				Expect(mySourcer.Get("Timeout")).To(Equal(""))
+				Expect(mySourcer.Explain("Timeout").Value).To(Equal("5"))
+			})
+		})
+
+		When("a profile is active", func() {
+			It("should layer the profile files between the base files and the local files", func() {
+				files := New(Options{Profile: "production"}).(*sourcer).sources.files
+				position := func(file string) int {
+					for pos, name := range files {
+						if name == file {
+							return pos
+						}
+					}
+					return -1
+				}
+				Expect(position("env.toml")).To(BeNumerically("<", position("config.production.yml")))
+				Expect(position("config.production.toml")).To(BeNumerically("<", position("env.production.yml")))
+				Expect(position("env.production.toml")).To(BeNumerically("<", position("config.local.yml")))
+				Expect(position(".env")).To(BeNumerically("<", position(".env.production")))
+				Expect(position(".env.production")).To(BeNumerically("<", position(".env.local")))
+			})
+
+			It("should read the profile from the configured environment variable", func() {
+				os.Setenv("APP_ENV", "staging")
+				os.Setenv("MY_PROFILE", "qa")
+				defer os.Unsetenv("APP_ENV")
+				defer os.Unsetenv("MY_PROFILE")
+				Expect(New(Options{}).(*sourcer).sources.files).To(ContainElement("config.staging.yml"))
+				Expect(New(Options{ProfileVariable: "MY_PROFILE"}).(*sourcer).sources.files).To(ContainElement("config.qa.json"))
+				Expect(New(Options{ProfileVariable: "MY_PROFILE"}).(*sourcer).sources.files).ToNot(ContainElement("config.staging.yml"))
+			})
+		})
+
+		When("several source files are given explicitly", func() {
+			BeforeEach(func() {
+				os.Setenv("Files_Colour", "Blue")
+				mockTerminalReader.EXPECT().Get(gomock.Any()).Return("", errors.New("not_found")).AnyTimes()
+			})
+
+			AfterEach(func() {
+				os.Unsetenv("Files_Colour")
+			})
+
+			It("should prioritise the files in the order given, beneath any overrides", func() {
+				mockFileReader.EXPECT().Read("/etc/app/config.yml").Return([]byte(strings.TrimSpace(`
+Files:
+  Colour: Red
+  Shape: Square
+				`)), nil)
+				mockFileReader.EXPECT().Read("config.local.yml").Return([]byte(strings.TrimSpace(`
+Files:
+  Shape: Circle
+				`)), nil)
+				mockFileReader.EXPECT().Read("missing.yml").Return(nil, errors.New("file not found"))
+				mySourcer.SourceFiles([]structs.SourceFile{
+					{Path: "missing.yml"},
+					{Path: "config.local.yml"},
+					{Path: "/etc/app/config.yml", Required: true},
+				})
+				Expect(mySourcer.Get("Files_Colour")).To(Equal("Blue"))
+				Expect(mySourcer.Get("Files_Shape")).To(Equal("Circle"))
+			})
+
+			It("should ignore overrides once they have been switched off", func() {
+				mockFileReader.EXPECT().Read("/etc/app/config.yml").Return([]byte(strings.TrimSpace(`
+Files:
+  Colour: Red
+				`)), nil)
+				mySourcer.SourceFiles([]structs.SourceFile{{Path: "/etc/app/config.yml", Required: true}})
+				mySourcer.UseOverrides(false)
+				Expect(mySourcer.Get("Files_Colour")).To(Equal("Red"))
+			})
+
+			It("should return blank if a required file cannot be read", func() {
+				mockFileReader.EXPECT().Read("config.local.yml").Return([]byte(strings.TrimSpace(`
+Files:
+  Shape: Circle
+				`)), nil)
+				mockFileReader.EXPECT().Read("/etc/app/config.yml").Return(nil, errors.New("file not found"))
+				mySourcer.SourceFiles([]structs.SourceFile{
+					{Path: "config.local.yml"},
+					{Path: "/etc/app/config.yml", Required: true},
+				})
+				Expect(mySourcer.Get("Files_Shape")).To(Equal(""))
+			})
+		})
+
 		When("there is only one source file and", func() {
 			When("the file reader is unable to read the file", func() {
 				It("should return blank when asked to Get a variable", func() {
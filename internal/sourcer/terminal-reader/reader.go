@@ -13,6 +13,7 @@ import (
 //go:generate mockgen -destination=../../mocks/mock-terminal-reader.go -package=mocks . TerminalReader
 type TerminalReader interface {
 	Get(key string) (string, error)
+	Keys() []string
 }
 
 type terminalReader struct {
@@ -27,6 +28,14 @@ func (t *terminalReader) Get(key string) (string, error) {
 	return val, nil
 }
 
+func (t *terminalReader) Keys() []string {
+	keys := make([]string, 0)
+	for key := range t.args {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
 func (t *terminalReader) parse() {
 	bits := strings.Split(strings.Join(os.Args, " "), "--")
 	inEscapedArg := false
@@ -0,0 +1,53 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package sourcer
+
+import (
+	"strings"
+
+	terminalReader "github.com/driscollos/config/internal/sourcer/terminal-reader"
+)
+
+type terminalSource struct {
+	reader terminalReader.TerminalReader
+}
+
+func (t terminalSource) Lookup(key string) (interface{}, bool) {
+	val, err := t.reader.Get(key)
+	if err != nil {
+		return nil, false
+	}
+	return val, true
+}
+
+// lookupPath reads the argument named by the path, so --Db_Host answers Db_Host and, with the separator ".", --Db.Host
+// answers Db.Host
+func (t terminalSource) lookupPath(path keyPath) (interface{}, bool) {
+	return t.Lookup(t.argument(path))
+}
+
+func (t terminalSource) locate(path keyPath) string {
+	return "--" + t.argument(path)
+}
+
+func (t terminalSource) paths() [][]string {
+	paths := make([][]string, 0)
+	for _, key := range t.reader.Keys() {
+		paths = append(paths, []string{key})
+	}
+	return paths
+}
+
+func (t terminalSource) argument(path keyPath) string {
+	return strings.Join(path.segments, path.separator)
+}
+
+func (t terminalSource) Keys() []string {
+	return t.reader.Keys()
+}
+
+func (t terminalSource) Name() string {
+	return "command line"
+}
@@ -0,0 +1,14 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package structs
+
+// DumpFormat is the format configuration is written in when it is dumped
+type DumpFormat string
+
+const (
+	DumpEnv  DumpFormat = "env"
+	DumpJSON DumpFormat = "json"
+	DumpYAML DumpFormat = "yaml"
+)
@@ -0,0 +1,50 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package structs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Candidate is a value which one source holds for a key
+type Candidate struct {
+	// Name names the source, such as a file path, "environment", "command line" or "default"
+	Name string
+
+	// Location pinpoints the value within the source - config.yml:12 for a line of a yaml file, $MYAPP_PORT for an
+	// environment variable, --Port for a terminal argument or default for a struct tag
+	Location string
+
+	// Value is the value as the source holds it, before any references are expanded
+	Value string
+}
+
+// Explanation describes where the value of a key came from
+type Explanation struct {
+	Key string
+
+	// Value is the value returned for the key
+	Value string
+
+	// Source supplied the value. It is nil when no source holds a value for the key
+	Source *Candidate
+
+	// Overridden lists the lower priority sources which also hold a value for the key, highest priority first
+	Overridden []Candidate
+}
+
+func (e Explanation) String() string {
+	if e.Source == nil {
+		return fmt.Sprintf("%s is not set", e.Key)
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("%s = %s from %s", e.Key, e.Value, e.Source.Location))
+	for _, candidate := range e.Overridden {
+		text.WriteString(fmt.Sprintf(", overriding %s from %s", candidate.Value, candidate.Location))
+	}
+	return text.String()
+}
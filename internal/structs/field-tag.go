@@ -0,0 +1,80 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package structs
+
+import (
+	"reflect"
+	"strings"
+)
+
+// FieldTag describes how a struct field is named in configuration, as read from its src, yaml and json tags. Populating
+// a struct and dumping one both read fields this way, so that a dumped struct can be read back in
+type FieldTag struct {
+	// Src lists the names in the src tag. The first is the key read for the field, named from the top level of the
+	// configuration, and the others are older names kept as aliases
+	Src []string
+
+	// Name is the name given by a yaml or json tag, relative to the struct holding the field. The yaml tag is preferred
+	// and neither is read when the field has a src tag
+	Name string
+
+	// Skip is set for fields tagged "-", which are never read
+	Skip bool
+
+	// Inline is set for fields whose own fields are read at the same level as the struct holding them
+	Inline bool
+}
+
+// ReadFieldTag reads how field is named in configuration
+func ReadFieldTag(field reflect.StructField) FieldTag {
+	tag := FieldTag{Src: SplitTag(field.Tag.Get("src"))}
+	if len(tag.Src) > 0 {
+		return tag
+	}
+
+	for _, key := range []string{"yaml", "json"} {
+		marshal, exists := field.Tag.Lookup(key)
+		if !exists {
+			continue
+		}
+
+		bits := strings.Split(marshal, ",")
+		if marshal == "-" {
+			tag.Skip = true
+			return tag
+		}
+		for _, flag := range bits[1:] {
+			if strings.TrimSpace(flag) == "inline" {
+				tag.Inline = true
+				return tag
+			}
+		}
+		if len(bits[0]) > 0 {
+			tag.Name = bits[0]
+			return tag
+		}
+	}
+	return tag
+}
+
+// SplitTag divides a comma separated tag into its names
+func SplitTag(tag string) []string {
+	names := make([]string, 0)
+	for _, name := range strings.Split(tag, ",") {
+		if name = strings.TrimSpace(name); len(name) > 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// IsEnabled reports whether a boolean struct tag such as required, literal or secret is switched on
+func IsEnabled(tag string) bool {
+	switch strings.ToLower(tag) {
+	case "yes", "1", "true", "on":
+		return true
+	}
+	return false
+}
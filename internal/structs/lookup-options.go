@@ -0,0 +1,20 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package structs
+
+// LookupOptions refine how a single value is found
+type LookupOptions struct {
+	// Literal requires keys to match exactly, rather than ignoring differences in case
+	Literal bool
+
+	// Env names the environment variable to read in place of the path, ignoring any prefix
+	Env string
+
+	// Flag names the terminal argument to read in place of the path
+	Flag string
+
+	// File gives the path to read from configuration files in place of the path
+	File string
+}
@@ -0,0 +1,19 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package structs
+
+// Lookup answers the questions asked while populating a struct. Sources which have been reloaded can be tried out
+// through a Lookup before they are used
+type Lookup interface {
+	Default(path, value string)
+	Get(path string, options ...LookupOptions) string
+	LookupOptions(path string, options LookupOptions)
+	Redact(path string)
+	Separator() string
+}
+
+// Validator checks the configuration a reload would give, through lookup, before it is used. An error abandons the
+// reload, otherwise commit is called once the reloaded configuration is in use
+type Validator func(lookup Lookup) (commit func(), err error)
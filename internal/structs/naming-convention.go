@@ -0,0 +1,16 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package structs
+
+// NamingConvention describes how the name of a struct field is written as a key, such as max_idle_conns for the
+// field MaxIdleConns
+type NamingConvention string
+
+const (
+	CamelCase          NamingConvention = "camel"
+	KebabCase          NamingConvention = "kebab"
+	ScreamingSnakeCase NamingConvention = "screaming"
+	SnakeCase          NamingConvention = "snake"
+)
@@ -0,0 +1,51 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package structs
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Redacted replaces the value of a secret wherever it would be shown
+const Redacted = "[REDACTED]"
+
+// Secret holds a value, such as a password, which must never be shown. It is populated like a string, but printing,
+// logging or marshalling it gives Redacted - use Value to read the real value
+type Secret string
+
+// Value returns the real value of the secret
+func (s Secret) Value() string {
+	return string(s)
+}
+
+func (s Secret) String() string {
+	return Redacted
+}
+
+func (s Secret) GoString() string {
+	return strconv.Quote(Redacted)
+}
+
+// Format redacts the secret whichever verb it is printed with
+func (s Secret) Format(f fmt.State, verb rune) {
+	if verb == 'q' || (verb == 'v' && f.Flag('#')) {
+		fmt.Fprint(f, strconv.Quote(Redacted))
+		return
+	}
+	fmt.Fprint(f, Redacted)
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(Redacted)), nil
+}
+
+func (s Secret) MarshalYAML() (interface{}, error) {
+	return Redacted, nil
+}
+
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(Redacted), nil
+}
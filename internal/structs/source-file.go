@@ -0,0 +1,12 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package structs
+
+// SourceFile is a configuration file to read from. A required file which cannot be read is an error, whereas an
+// optional file is skipped
+type SourceFile struct {
+	Path     string
+	Required bool
+}
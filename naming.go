@@ -0,0 +1,27 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package config
+
+import (
+	"github.com/driscollos/config/internal/structs"
+)
+
+// NamingConvention describes how the name of a struct field is written as a key. Choose the conventions to try with
+// WithNaming, or per field with the naming tag eg. `naming:"snake,kebab"`
+type NamingConvention = structs.NamingConvention
+
+const (
+	// CamelCase writes MaxIdleConns as maxIdleConns
+	CamelCase = structs.CamelCase
+
+	// KebabCase writes MaxIdleConns as max-idle-conns
+	KebabCase = structs.KebabCase
+
+	// ScreamingSnakeCase writes MaxIdleConns as MAX_IDLE_CONNS, as is usual for environment variables
+	ScreamingSnakeCase = structs.ScreamingSnakeCase
+
+	// SnakeCase writes MaxIdleConns as max_idle_conns
+	SnakeCase = structs.SnakeCase
+)
@@ -0,0 +1,91 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package config
+
+import (
+	"time"
+
+	"github.com/driscollos/config/internal/populator"
+	"github.com/driscollos/config/internal/sourcer"
+	"github.com/driscollos/config/internal/structs"
+)
+
+// Option customises the Config returned by New
+type Option func(options *options)
+
+type options struct {
+	populator populator.Options
+	sourcer   sourcer.Options
+}
+
+// WithSources replaces the default sources with the sources given, in priority order - the first source which knows
+// a key provides its value. Any source left out of the list is not consulted at all
+func WithSources(sources ...Source) Option {
+	return func(options *options) {
+		options.sourcer.Sources = sources
+	}
+}
+
+// WithProfile selects the active profile. Files for the profile, such as config.production.yml, env.production.json
+// or .env.production, are layered over the default configuration files but beneath the *.local files. Without this
+// option the profile is read from the APP_ENV environment variable
+func WithProfile(profile string) Option {
+	return func(options *options) {
+		options.sourcer.Profile = profile
+	}
+}
+
+// WithProfileVariable reads the active profile from the environment variable named instead of APP_ENV
+func WithProfileVariable(name string) Option {
+	return func(options *options) {
+		options.sourcer.ProfileVariable = name
+	}
+}
+
+// WithEnvPrefix reads environment variables with the given prefix in the default sources, so that services sharing a
+// host do not collide - with the prefix MYAPP_ the key Port is read from MYAPP_PORT. When sources are chosen with
+// WithSources use EnvironmentWithPrefix instead
+func WithEnvPrefix(prefix string) Option {
+	return func(options *options) {
+		options.sourcer.EnvPrefix = prefix
+	}
+}
+
+// WithSeparator divides the levels of nesting in keys with separator instead of an underscore, so that with "." the
+// key Db.Max_Connections names max_connections within Db. Environment variables are always read with the levels
+// joined by underscores, as DB_MAX_CONNECTIONS
+func WithSeparator(separator string) Option {
+	return func(options *options) {
+		options.sourcer.Separator = separator
+	}
+}
+
+// WithNaming chooses the naming conventions tried when a struct field has no src tag. The field name is always tried
+// first as it is written, then each convention in turn - so with SnakeCase the field MaxIdleConns is also read from
+// max_idle_conns. Only field names are used unless this option is given
+func WithNaming(conventions ...NamingConvention) Option {
+	return func(options *options) {
+		options.populator.Naming = append(make([]structs.NamingConvention, 0), conventions...)
+	}
+}
+
+// Logger receives warnings, such as a deprecated key being used. *log.Logger satisfies it
+type Logger = populator.Logger
+
+// WithLogger sends warnings, such as a deprecated key being used, to logger instead of the standard logger
+func WithLogger(logger Logger) Option {
+	return func(options *options) {
+		options.populator.Logger = logger
+	}
+}
+
+// WithPolling makes Watch check the configuration files for changes every interval, rather than relying on the
+// operating system to report them. Use it where files live on a file system which does not report changes, such as
+// a network mount. Without it, files are only checked this way if changes cannot be reported
+func WithPolling(interval time.Duration) Option {
+	return func(options *options) {
+		options.sourcer.PollInterval = interval
+	}
+}
@@ -0,0 +1,14 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package config
+
+import (
+	"github.com/driscollos/config/internal/structs"
+)
+
+// Secret holds a value, such as a password, which must never be shown. Populate fills it like a string, but printing,
+// logging or marshalling it to json or yaml gives [REDACTED] - use its Value method to read the real value. Plain
+// string fields can be redacted in the output of Explain, Provenance, Dump and DumpStruct with the tag secret:"true"
+type Secret = structs.Secret
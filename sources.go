@@ -0,0 +1,73 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package config
+
+import (
+	"github.com/driscollos/config/internal/sourcer"
+	"github.com/driscollos/config/internal/structs"
+)
+
+// Source is a provider of configuration values. Implement this interface to supply configuration from your own
+// systems and pass it to New using WithSources. Lookup should report false for keys the source does not know so that
+// lower priority sources are consulted
+type Source = sourcer.Source
+
+// CommandLine returns a source which reads terminal arguments in the form --Name value
+func CommandLine() Source {
+	return sourcer.NewTerminalSource()
+}
+
+// Environment returns a source which reads environment variables. Variable names are matched regardless of case
+func Environment() Source {
+	return sourcer.NewEnvironmentSource("")
+}
+
+// EnvironmentWithPrefix returns a source which reads environment variables whose names start with prefix, leaving
+// the prefix out of the key - with the prefix MYAPP_ the key Port is read from MYAPP_PORT
+func EnvironmentWithPrefix(prefix string) Source {
+	return sourcer.NewEnvironmentSource(prefix)
+}
+
+// File returns a source which reads the configuration file at path, choosing the format by its extension. If the file
+// does not exist it is skipped
+func File(path string) Source {
+	return sourcer.NewFileSource(path, false)
+}
+
+// Directory returns a source which reads a directory of files, where each file name is a key and the content of the
+// file is its value - the layout used by kubernetes ConfigMaps and Secrets, or by docker secrets under /run/secrets.
+// Nested directories become nested keys, so the file db/password answers Db_Password. If the directory does not exist
+// it is skipped
+func Directory(path string) Source {
+	return sourcer.NewDirectorySource(path, false)
+}
+
+// DropIn returns a source which reads every configuration file in a conf.d style directory, in the way nginx or
+// systemd drop-ins work. Fragments are merged in lexical order of their file names, so a value in 20-database.yml
+// overrides the same value in 10-defaults.yml. Use Origin to find out which fragment supplied a value. If the
+// directory does not exist it is skipped
+func DropIn(path string) Source {
+	return sourcer.NewDropInSource(path, false)
+}
+
+// SourceFile is a configuration file given to SourceFiles
+type SourceFile = structs.SourceFile
+
+// Required describes a configuration file which must exist - if it cannot be read no configuration will be available
+func Required(path string) SourceFile {
+	return SourceFile{Path: path, Required: true}
+}
+
+// Optional describes a configuration file which is skipped if it does not exist
+func Optional(path string) SourceFile {
+	return SourceFile{Path: path}
+}
+
+// RegisterFormat teaches every Config how to read files ending with extension. The decode function receives the raw
+// contents of the file and should return its values as nested maps, in the same shape a yaml or json file decodes to.
+// Registering an extension which is already known replaces the built in decoder
+func RegisterFormat(extension string, decode func(source []byte) (map[string]interface{}, error)) {
+	sourcer.RegisterFormat(extension, decode)
+}
@@ -0,0 +1,19 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package config
+
+// Validator is implemented by configuration structs which check their own values. A struct given to Register which
+// implements it must pass Validate before a reload is accepted
+type Validator interface {
+	Validate() error
+}
+
+// validate checks container if it implements Validator
+func validate(container interface{}) error {
+	if validator, ok := container.(Validator); ok {
+		return validator.Validate()
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+// Copyright 2022 John Driscoll (https://github.com/codebyjdd)
+// This code is licensed under the MIT license
+// Please see LICENSE.md
+
+package config
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// handles keeps a single handle for each parameter and type asked for, so that asking again for the same handle does
+// not add to the work done on every reload. Each handle is only refreshed when a reload changes its parameter, or a
+// parameter nested beneath it
+type handles struct {
+	mutex      sync.Mutex
+	subscribed bool
+	tracked    map[string]*tracked
+}
+
+type tracked struct {
+	param   string
+	handle  interface{}
+	refresh func()
+}
+
+// handle returns the handle of the kind given for param, creating it the first time it is asked for. create makes the
+// handle along with the function which refreshes it from the configuration. Refreshes are made one at a time, so an
+// older value never replaces a newer one
+func (h *handles) handle(c config, kind, param string, create func() (interface{}, func())) interface{} {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if existing, exists := h.tracked[kind+":"+param]; exists {
+		return existing.handle
+	}
+
+	if !h.subscribed {
+		separator := c.source.Separator()
+		c.source.OnChange(func(changedKeys []string) {
+			h.changed(changedKeys, separator)
+		})
+		h.subscribed = true
+	}
+	if h.tracked == nil {
+		h.tracked = make(map[string]*tracked)
+	}
+
+	handle, refresh := create()
+	h.tracked[kind+":"+param] = &tracked{param: param, handle: handle, refresh: refresh}
+	refresh()
+	return handle
+}
+
+// changed refreshes the handles whose parameters are among the changed keys
+func (h *handles) changed(changedKeys []string, separator string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for _, handle := range h.tracked {
+		for _, key := range changedKeys {
+			if affects(key, handle.param, separator) {
+				handle.refresh()
+				break
+			}
+		}
+	}
+}
+
+// affects reports whether a change to key alters the value of param, either by being param or lying beneath it. Keys
+// are matched regardless of case, as they are when they are looked up
+func affects(key, param, separator string) bool {
+	if strings.EqualFold(key, param) {
+		return true
+	}
+	return len(key) > len(param+separator) && strings.EqualFold(key[:len(param+separator)], param+separator)
+}
+
+// BoolValue holds the current value of a parameter, which is kept up to date whenever the configuration is reloaded.
+// It can be read from any number of goroutines without locking
+type BoolValue struct {
+	value int32
+}
+
+// Get returns the current value
+func (v *BoolValue) Get() bool {
+	return atomic.LoadInt32(&v.value) == 1
+}
+
+func (v *BoolValue) set(val bool) {
+	stored := int32(0)
+	if val {
+		stored = 1
+	}
+	atomic.StoreInt32(&v.value, stored)
+}
+
+// DurationValue holds the current value of a parameter, which is kept up to date whenever the configuration is
+// reloaded. It can be read from any number of goroutines without locking
+type DurationValue struct {
+	value int64
+}
+
+// Get returns the current value
+func (v *DurationValue) Get() time.Duration {
+	return time.Duration(atomic.LoadInt64(&v.value))
+}
+
+func (v *DurationValue) set(val time.Duration) {
+	atomic.StoreInt64(&v.value, int64(val))
+}
+
+// FloatValue holds the current value of a parameter, which is kept up to date whenever the configuration is
+// reloaded. It can be read from any number of goroutines without locking
+type FloatValue struct {
+	bits uint64
+}
+
+// Get returns the current value
+func (v *FloatValue) Get() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&v.bits))
+}
+
+func (v *FloatValue) set(val float64) {
+	atomic.StoreUint64(&v.bits, math.Float64bits(val))
+}
+
+// IntValue holds the current value of a parameter, which is kept up to date whenever the configuration is reloaded.
+// It can be read from any number of goroutines without locking
+type IntValue struct {
+	value int64
+}
+
+// Get returns the current value
+func (v *IntValue) Get() int {
+	return int(atomic.LoadInt64(&v.value))
+}
+
+func (v *IntValue) set(val int) {
+	atomic.StoreInt64(&v.value, int64(val))
+}
+
+// StringValue holds the current value of a parameter, which is kept up to date whenever the configuration is
+// reloaded. It can be read from any number of goroutines without locking
+type StringValue struct {
+	value atomic.Value
+}
+
+// Get returns the current value
+func (v *StringValue) Get() string {
+	val, _ := v.value.Load().(string)
+	return val
+}
+
+func (v *StringValue) set(val string) {
+	v.value.Store(val)
+}
+
+// StructValue holds the current copy of a struct given to Register, which is replaced whenever a reload is accepted.
+// It can be read from any number of goroutines without locking
+type StructValue struct {
+	value atomic.Value
+}
+
+// Get returns a pointer to a copy of the struct as it was populated by the last accepted reload, of the same type as
+// the pointer given to Register. Each call returns a new copy, so changing it does not affect other readers - but the
+// copy is shallow, so maps, slices and pointers within it are shared and must not be changed
+func (v *StructValue) Get() interface{} {
+	current, _ := v.value.Load().(reflect.Value)
+	fresh := reflect.New(current.Elem().Type())
+	fresh.Elem().Set(current.Elem())
+	return fresh.Interface()
+}
+
+func (v *StructValue) set(val reflect.Value) {
+	v.value.Store(val)
+}